@@ -0,0 +1,115 @@
+package command
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Options The options of an invoked command keyed by name
+type Options map[string]*discordgo.ApplicationCommandInteractionDataOption
+
+func ParseOptions(options []*discordgo.ApplicationCommandInteractionDataOption) Options {
+
+	result := make(Options, len(options))
+
+	for _, option := range options {
+		result[option.Name] = option
+	}
+
+	return result
+}
+
+// Subcommand Returns the name and options of the invoked subcommand or subcommand group
+func (options Options) Subcommand() (string, Options, bool) {
+
+	for name, option := range options {
+		if option.Type == discordgo.ApplicationCommandOptionSubCommand || option.Type == discordgo.ApplicationCommandOptionSubCommandGroup {
+			return name, ParseOptions(option.Options), true
+		}
+	}
+
+	return "", nil, false
+}
+
+// Focused Returns the name and typed text of the option being autocompleted, looking inside the invoked subcommand
+func (options Options) Focused() (string, string, bool) {
+
+	for name, option := range options {
+
+		if option.Focused {
+			text, _ := option.Value.(string)
+			return name, text, true
+		}
+
+		if option.Type == discordgo.ApplicationCommandOptionSubCommand || option.Type == discordgo.ApplicationCommandOptionSubCommandGroup {
+			if focusedName, text, ok := ParseOptions(option.Options).Focused(); ok {
+				return focusedName, text, true
+			}
+		}
+	}
+
+	return "", "", false
+}
+
+func (options Options) String(name string) (string, bool) {
+
+	option, ok := options[name]
+	if !ok || option.Type != discordgo.ApplicationCommandOptionString {
+		return "", false
+	}
+
+	return option.StringValue(), true
+}
+
+func (options Options) Int(name string) (int64, bool) {
+
+	option, ok := options[name]
+	if !ok || option.Type != discordgo.ApplicationCommandOptionInteger {
+		return 0, false
+	}
+
+	return option.IntValue(), true
+}
+
+func (options Options) Float(name string) (float64, bool) {
+
+	option, ok := options[name]
+	if !ok || option.Type != discordgo.ApplicationCommandOptionNumber {
+		return 0, false
+	}
+
+	return option.FloatValue(), true
+}
+
+func (options Options) Bool(name string) (bool, bool) {
+
+	option, ok := options[name]
+	if !ok || option.Type != discordgo.ApplicationCommandOptionBoolean {
+		return false, false
+	}
+
+	return option.BoolValue(), true
+}
+
+// ChannelID Returns the ID of a channel option without fetching the channel
+func (options Options) ChannelID(name string) (string, bool) {
+
+	option, ok := options[name]
+	if !ok || option.Type != discordgo.ApplicationCommandOptionChannel {
+		return "", false
+	}
+
+	channelID, ok := option.Value.(string)
+	return channelID, ok
+}
+
+// RequireString Same as String, but returns an error meant for the user if the option is missing
+func (options Options) RequireString(name string) (string, error) {
+
+	value, ok := options.String(name)
+	if !ok {
+		return "", fmt.Errorf("missing option %q", name)
+	}
+
+	return value, nil
+}
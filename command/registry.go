@@ -0,0 +1,218 @@
+package command
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"log"
+	"strings"
+	"sync"
+)
+
+type Handler func(session *discordgo.Session, interaction *discordgo.InteractionCreate, options Options) error
+
+// ComponentHandler Handles message components whose custom ID starts with "<command name>:"
+type ComponentHandler func(session *discordgo.Session, interaction *discordgo.InteractionCreate, customID string) error
+
+// AutocompleteHandler Suggests values for the option being typed, for options with Autocomplete set
+type AutocompleteHandler func(options Options) []*discordgo.ApplicationCommandOptionChoice
+
+// maxAutocompleteChoices Discord rejects autocomplete responses with more choices
+const maxAutocompleteChoices = 25
+
+type Command struct {
+	Definition *discordgo.ApplicationCommand
+	// GuildID Registers the command only for this guild, empty for a global command
+	GuildID             string
+	Handler             Handler
+	ComponentHandler    ComponentHandler
+	AutocompleteHandler AutocompleteHandler
+}
+
+// Provider Anything that contributes application commands, usually a module.Module
+type Provider interface {
+	Commands() []*Command
+}
+
+type Registry struct {
+	mutex    sync.RWMutex
+	commands map[string]*Command
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		commands: map[string]*Command{},
+	}
+}
+
+func (registry *Registry) Add(commands ...*Command) error {
+
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	for _, command := range commands {
+
+		name := command.Definition.Name
+		if _, exists := registry.commands[name]; exists {
+			return fmt.Errorf("command %q is already registered", name)
+		}
+
+		registry.commands[name] = command
+	}
+
+	return nil
+}
+
+func (registry *Registry) AddProvider(provider Provider) error {
+	return registry.Add(provider.Commands()...)
+}
+
+func (registry *Registry) Get(name string) (*Command, bool) {
+
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	command, ok := registry.commands[name]
+	return command, ok
+}
+
+// Register Overwrites the application commands of each guild, and the global ones, with the added commands
+func (registry *Registry) Register(session *discordgo.Session) error {
+
+	registry.mutex.RLock()
+
+	definitionsByGuild := map[string][]*discordgo.ApplicationCommand{
+		// Always overwrite the global commands so removed ones don't linger
+		"": {},
+	}
+
+	for _, command := range registry.commands {
+		definitionsByGuild[command.GuildID] = append(definitionsByGuild[command.GuildID], command.Definition)
+	}
+
+	registry.mutex.RUnlock()
+
+	for guildID, definitions := range definitionsByGuild {
+		_, err := session.ApplicationCommandBulkOverwrite(session.State.User.ID, guildID, definitions)
+		if err != nil {
+			return fmt.Errorf("failed to register commands for guild (%s): %w", guildID, err)
+		}
+	}
+
+	return nil
+}
+
+// HandleInteraction Routes an interaction to the command that owns it, meant to be passed to Session.AddHandler
+func (registry *Registry) HandleInteraction(session *discordgo.Session, interaction *discordgo.InteractionCreate) {
+
+	var err error
+	var name string
+
+	switch interaction.Type {
+
+	case discordgo.InteractionApplicationCommand:
+
+		commandData := interaction.ApplicationCommandData()
+		name = commandData.Name
+
+		command, ok := registry.Get(name)
+		if !ok || command.Handler == nil {
+			return
+		}
+
+		err = command.Handler(session, interaction, ParseOptions(commandData.Options))
+
+	case discordgo.InteractionMessageComponent:
+
+		customID := interaction.MessageComponentData().CustomID
+		name = strings.SplitN(customID, ":", 2)[0]
+
+		command, ok := registry.Get(name)
+		if !ok || command.ComponentHandler == nil {
+			return
+		}
+
+		err = command.ComponentHandler(session, interaction, customID)
+
+	case discordgo.InteractionApplicationCommandAutocomplete:
+
+		commandData := interaction.ApplicationCommandData()
+		name = commandData.Name
+
+		command, ok := registry.Get(name)
+		if !ok || command.AutocompleteHandler == nil {
+			return
+		}
+
+		choices := command.AutocompleteHandler(ParseOptions(commandData.Options))
+		if len(choices) > maxAutocompleteChoices {
+			choices = choices[:maxAutocompleteChoices]
+		}
+
+		// Autocomplete can't be answered with a message, so errors are only logged
+		err = session.InteractionRespond(interaction.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+			Data: &discordgo.InteractionResponseData{Choices: choices},
+		})
+
+		if err != nil {
+			log.Printf("%v", fmt.Errorf("failed to autocomplete command %q: %w", name, err))
+		}
+
+		return
+
+	default:
+		return
+	}
+
+	if err != nil {
+
+		log.Printf("%v", fmt.Errorf("command %q failed: %w", name, err))
+
+		err = RespondEphemeral(session, interaction, fmt.Sprintf("Failed: %v", err))
+		if err != nil {
+			log.Printf("%v", fmt.Errorf("failed to respond to command %q: %w", name, err))
+		}
+	}
+}
+
+func Respond(session *discordgo.Session, interaction *discordgo.InteractionCreate, content string) error {
+	return session.InteractionRespond(interaction.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: content},
+	})
+}
+
+// RespondEphemeral Responds with a message only the user who invoked the command can see
+func RespondEphemeral(session *discordgo.Session, interaction *discordgo.InteractionCreate, content string) error {
+	return session.InteractionRespond(interaction.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   uint64(discordgo.MessageFlagsEphemeral),
+		},
+	})
+}
+
+// Defer Acknowledges the interaction so the response can be sent later with EditResponse, for handlers that take longer than 3 seconds
+func Defer(session *discordgo.Session, interaction *discordgo.InteractionCreate, ephemeral bool) error {
+
+	response := &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	}
+
+	if ephemeral {
+		response.Data = &discordgo.InteractionResponseData{Flags: uint64(discordgo.MessageFlagsEphemeral)}
+	}
+
+	return session.InteractionRespond(interaction.Interaction, response)
+}
+
+func EditResponse(session *discordgo.Session, interaction *discordgo.InteractionCreate, content string) error {
+	_, err := session.InteractionResponseEdit(interaction.Interaction, &discordgo.WebhookEdit{Content: content})
+	return err
+}
+
+// HasPermission Checks if the member who invoked the interaction has the permission in the guild
+func HasPermission(interaction *discordgo.InteractionCreate, permission int64) bool {
+	return interaction.Member != nil && interaction.Member.Permissions&permission == permission
+}
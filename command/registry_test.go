@@ -0,0 +1,81 @@
+package command
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRegistry_Add(test *testing.T) {
+
+	registry := NewRegistry()
+
+	ping := &Command{Definition: &discordgo.ApplicationCommand{Name: "ping"}}
+
+	assert.NoError(test, registry.Add(ping))
+	assert.Error(test, registry.Add(&Command{Definition: &discordgo.ApplicationCommand{Name: "ping"}}))
+
+	command, ok := registry.Get("ping")
+	assert.True(test, ok)
+	assert.Same(test, ping, command)
+}
+
+func TestOptions_Subcommand(test *testing.T) {
+
+	options := ParseOptions([]*discordgo.ApplicationCommandInteractionDataOption{
+		{
+			Name: "add",
+			Type: discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: "url", Type: discordgo.ApplicationCommandOptionString, Value: "https://example.com/feed"},
+				{Name: "limit", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(5)},
+				{Name: "channel", Type: discordgo.ApplicationCommandOptionChannel, Value: "1234"},
+			},
+		},
+	})
+
+	name, subOptions, ok := options.Subcommand()
+	assert.True(test, ok)
+	assert.Equal(test, "add", name)
+
+	url, ok := subOptions.String("url")
+	assert.True(test, ok)
+	assert.Equal(test, "https://example.com/feed", url)
+
+	limit, ok := subOptions.Int("limit")
+	assert.True(test, ok)
+	assert.Equal(test, int64(5), limit)
+
+	channelID, ok := subOptions.ChannelID("channel")
+	assert.True(test, ok)
+	assert.Equal(test, "1234", channelID)
+
+	// Wrong type or missing
+	_, ok = subOptions.String("limit")
+	assert.False(test, ok)
+
+	_, err := subOptions.RequireString("missing")
+	assert.Error(test, err)
+}
+
+func TestOptions_Focused(test *testing.T) {
+
+	options := ParseOptions([]*discordgo.ApplicationCommandInteractionDataOption{
+		{
+			Name: "add",
+			Type: discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: "url", Type: discordgo.ApplicationCommandOptionString, Value: "https://example.com/feed"},
+				{Name: "channel", Type: discordgo.ApplicationCommandOptionString, Value: "ai", Focused: true},
+			},
+		},
+	})
+
+	name, text, ok := options.Focused()
+	assert.True(test, ok)
+	assert.Equal(test, "channel", name)
+	assert.Equal(test, "ai", text)
+
+	_, _, ok = ParseOptions(nil).Focused()
+	assert.False(test, ok)
+}
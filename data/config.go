@@ -0,0 +1,167 @@
+package data
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/json-iterator/go"
+	"os"
+	"privateInfoBot/utils"
+)
+
+// FeedsConfig The contents of the feeds file
+type FeedsConfig struct {
+	// Profiles The transport profiles feeds can refer to by name
+	Profiles map[string]TransportProfile `json:"profiles,omitempty"`
+	Feeds    []RSSFeed                   `json:"feeds"`
+}
+
+// Profile Returns the feed's transport profile, the zero profile if it doesn't have one
+func (config FeedsConfig) Profile(feed RSSFeed) TransportProfile {
+
+	if feed.Transport == nil {
+		return TransportProfile{}
+	}
+
+	return config.Profiles[*feed.Transport]
+}
+
+// Validate Checks the profiles, that every feed refers to a known one and that the templates, filters and colors parse
+func (config FeedsConfig) Validate() error {
+
+	for name, profile := range config.Profiles {
+		if err := profile.validate(); err != nil {
+			return fmt.Errorf("transport profile %s: %w", name, err)
+		}
+	}
+
+	for _, feed := range config.Feeds {
+
+		if feed.Transport != nil {
+			if _, ok := config.Profiles[*feed.Transport]; !ok {
+				return fmt.Errorf("feed %s uses unknown transport profile: %s", feed.FeedURL, *feed.Transport)
+			}
+		}
+
+		if feed.Templates != nil {
+			if _, err := feed.Templates.Parse(); err != nil {
+				return fmt.Errorf("feed %s: %w", feed.FeedURL, err)
+			}
+		}
+
+		if feed.Versions != nil {
+			if _, err := feed.Versions.Parse(); err != nil {
+				return fmt.Errorf("feed %s: %w", feed.FeedURL, err)
+			}
+		}
+
+		if err := validateErrorPolicy(feed.ErrorPolicy); err != nil {
+			return fmt.Errorf("feed %s: %w", feed.FeedURL, err)
+		}
+
+		// Formatting fails on a color that doesn't parse, so the feed would never post
+		if feed.Color != nil {
+			if _, err := ParseColor(*feed.Color); err != nil {
+				return fmt.Errorf("feed %s: %w", feed.FeedURL, err)
+			}
+		}
+
+		if feed.Identity != nil {
+			switch *feed.Identity {
+			case GUIDIdentity, LinkIdentity, TitleIdentity, ContentHashIdentity:
+			default:
+				return fmt.Errorf("feed %s has an unknown identity: %s", feed.FeedURL, *feed.Identity)
+			}
+		}
+
+		if feed.AgeField != nil {
+			switch *feed.AgeField {
+			case PublishedAge, UpdatedAge, EitherAge, BothAges:
+			default:
+				return fmt.Errorf("feed %s has an unknown ageField: %s", feed.FeedURL, *feed.AgeField)
+			}
+		}
+
+		// A limit of 0 forgets every item on each check, so they'd all be posted again
+		if feed.SeenLimit != nil && *feed.SeenLimit <= 0 {
+			return fmt.Errorf("feed %s has a seenLimit that isn't positive: %d", feed.FeedURL, *feed.SeenLimit)
+		}
+
+		// An age of 0 makes every item too old to post on its own
+		if feed.MaxAge != nil && *feed.MaxAge <= 0 {
+			return fmt.Errorf("feed %s has a maxAge that isn't positive: %s", feed.FeedURL, *feed.MaxAge)
+		}
+
+		if feed.SeenTTL != nil && *feed.SeenTTL <= 0 {
+			return fmt.Errorf("feed %s has a seenTTL that isn't positive: %s", feed.FeedURL, *feed.SeenTTL)
+		}
+
+		for _, policy := range []*SensitivePolicy{feed.NSFW, feed.Spoilers} {
+			if policy != nil && *policy != ShowSensitive && *policy != SpoilerSensitive && *policy != SkipSensitive {
+				return fmt.Errorf("feed %s has an unknown nsfw or spoilers setting: %s", feed.FeedURL, *policy)
+			}
+		}
+
+		if feed.Kernel != nil {
+			if err := feed.Kernel.validate(); err != nil {
+				return fmt.Errorf("feed %s: %w", feed.FeedURL, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ReadRSSFeeds Reads the feeds file, older files that are only a list of feeds are read as having no profiles
+func ReadRSSFeeds(filePath string) (FeedsConfig, error) {
+
+	feedsJson, err := os.ReadFile(filePath)
+	if err != nil {
+		return FeedsConfig{}, fmt.Errorf("failed to read rssFeeds: %w", err)
+	}
+
+	var config FeedsConfig
+
+	if trimmed := bytes.TrimSpace(feedsJson); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = jsoniter.Unmarshal(feedsJson, &config.Feeds)
+	} else {
+		err = jsoniter.Unmarshal(feedsJson, &config)
+	}
+
+	if err != nil {
+		return FeedsConfig{}, fmt.Errorf("failed to read rssFeeds: %w", err)
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return FeedsConfig{}, fmt.Errorf("failed to read rssFeeds: %w", err)
+	}
+
+	return config, nil
+}
+
+func WriteRSSFeeds(filePath string, config FeedsConfig) error {
+
+	err := utils.WriteJsonAfterMakeDirs(filePath, config)
+	if err != nil {
+		return fmt.Errorf("failed to write rssFeeds: %w", err)
+	}
+
+	return nil
+}
+
+func ReadChannels(filePath string) (map[string]uint64, error) {
+
+	channelsJson, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read channels: %w", err)
+	}
+
+	channels := map[string]uint64{}
+
+	err = jsoniter.Unmarshal(channelsJson, &channels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read channels: %w", err)
+	}
+
+	return channels, nil
+}
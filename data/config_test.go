@@ -0,0 +1,140 @@
+package data
+
+import (
+	"github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadRSSFeeds(test *testing.T) {
+
+	tests := []struct {
+		testName      string
+		json          string
+		expectedFeeds int
+		expectError   bool
+	}{
+		{testName: "legacyList", json: `[{"channelName": "linuxUpdates", "feedURL": "https://www.kernel.org/feeds/kdist.xml"}]`, expectedFeeds: 1},
+		{testName: "withProfiles", json: `{"profiles": {"reddit": {"disableHTTP2": true}}, "feeds": [{"feedURL": "https://www.reddit.com/r/longevity.rss", "transport": "reddit"}]}`, expectedFeeds: 1},
+		{testName: "unknownProfile", json: `{"feeds": [{"feedURL": "https://www.reddit.com/r/longevity.rss", "transport": "reddit"}]}`, expectError: true},
+		{testName: "emptyFind", json: `{"profiles": {"broken": {"bodyReplacements": [{"replace": "x"}]}}, "feeds": []}`, expectError: true},
+		{testName: "errorPolicy", json: `{"feeds": [{"feedURL": "https://example.com/feed", "errorPolicy": "skip"}]}`, expectedFeeds: 1},
+		{testName: "unknownErrorPolicy", json: `{"feeds": [{"feedURL": "https://example.com/feed", "errorPolicy": "ignore"}]}`, expectError: true},
+		{testName: "identity", json: `{"feeds": [{"feedURL": "https://example.com/feed", "identity": "hash"}]}`, expectedFeeds: 1},
+		{testName: "unknownIdentity", json: `{"feeds": [{"feedURL": "https://example.com/feed", "identity": "url"}]}`, expectError: true},
+		{testName: "ageField", json: `{"feeds": [{"feedURL": "https://example.com/feed", "ageField": "either"}]}`, expectedFeeds: 1},
+		{testName: "unknownAgeField", json: `{"feeds": [{"feedURL": "https://example.com/feed", "ageField": "created"}]}`, expectError: true},
+		{testName: "seenLimits", json: `{"feeds": [{"feedURL": "https://example.com/feed", "seenLimit": 100, "seenTTL": "7d"}]}`, expectedFeeds: 1},
+		{testName: "zeroSeenLimit", json: `{"feeds": [{"feedURL": "https://example.com/feed", "seenLimit": 0}]}`, expectError: true},
+		{testName: "negativeSeenLimit", json: `{"feeds": [{"feedURL": "https://example.com/feed", "seenLimit": -1}]}`, expectError: true},
+		{testName: "zeroSeenTTL", json: `{"feeds": [{"feedURL": "https://example.com/feed", "seenTTL": "0s"}]}`, expectError: true},
+		{testName: "negativeSeenTTL", json: `{"feeds": [{"feedURL": "https://example.com/feed", "seenTTL": "-1h"}]}`, expectError: true},
+		{testName: "maxAge", json: `{"feeds": [{"feedURL": "https://example.com/feed", "maxAge": "30d"}]}`, expectedFeeds: 1},
+		{testName: "zeroMaxAge", json: `{"feeds": [{"feedURL": "https://example.com/feed", "maxAge": "0s"}]}`, expectError: true},
+		{testName: "negativeMaxAge", json: `{"feeds": [{"feedURL": "https://example.com/feed", "maxAge": "-1h"}]}`, expectError: true},
+		{testName: "color", json: `{"feeds": [{"feedURL": "https://example.com/feed", "color": "#E1AD01"}]}`, expectedFeeds: 1},
+		{testName: "badColor", json: `{"feeds": [{"feedURL": "https://example.com/feed", "color": "#nope"}]}`, expectError: true},
+	}
+
+	for _, testData := range tests {
+		test.Run(testData.testName, func(test *testing.T) {
+
+			filePath := filepath.Join(test.TempDir(), "rssFeeds.json")
+			assert.NoError(test, os.WriteFile(filePath, []byte(testData.json), 0644))
+
+			config, err := ReadRSSFeeds(filePath)
+			if testData.expectError {
+				assert.Error(test, err)
+				return
+			}
+
+			assert.NoError(test, err)
+			assert.Len(test, config.Feeds, testData.expectedFeeds)
+		})
+	}
+}
+
+func TestDuration_MarshalJSON(test *testing.T) {
+
+	for _, text := range []string{"30d", "36h", "1h30m", "90m", "1m30s", "500ms", "unlimited"} {
+
+		var duration Duration
+		assert.NoError(test, jsoniter.Unmarshal([]byte(`"`+text+`"`), &duration))
+
+		written, err := jsoniter.Marshal(duration)
+		assert.NoError(test, err)
+
+		var rewritten Duration
+		assert.NoError(test, jsoniter.Unmarshal(written, &rewritten))
+		assert.Equal(test, duration, rewritten, text)
+	}
+
+	// Saving the feeds file keeps durations the way they were typed
+	for text, expected := range map[string]string{"30d": `"30d"`, "720h": `"30d"`, "36h": `"36h"`, "1h30m": `"1h30m"`, "90s": `"1m30s"`} {
+
+		var duration Duration
+		assert.NoError(test, jsoniter.Unmarshal([]byte(`"`+text+`"`), &duration))
+
+		written, err := jsoniter.Marshal(duration)
+		assert.NoError(test, err)
+		assert.Equal(test, expected, string(written), text)
+	}
+}
+
+func TestTransportProfile_RepairBody(test *testing.T) {
+
+	profile := TransportProfile{
+		BodyReplacements: []BodyReplacement{
+			{Find: "text/html", Replace: "application/rss+xml", Count: 1},
+			{Find: "&nbsp;", Replace: " "},
+		},
+	}
+
+	repaired := profile.RepairBody(`type="text/html" type="text/html" a&nbsp;b&nbsp;c`)
+	assert.Equal(test, `type="application/rss+xml" type="text/html" a b c`, repaired)
+}
+
+func TestReadScrapeSources(test *testing.T) {
+
+	tests := []struct {
+		testName        string
+		json            string
+		expectedSources int
+		expectError     bool
+	}{
+		{testName: "valid", json: `{"sources": [{"name": "roadmap", "url": "https://example.com/roadmap", "selectors": {"container": "#content > div:nth-child(6)", "heading": "p", "entry": "ul"}}]}`, expectedSources: 1},
+		{testName: "badSelector", json: `{"sources": [{"name": "roadmap", "url": "https://example.com/roadmap", "selectors": {"container": "div[", "entry": "ul"}}]}`, expectError: true},
+		{testName: "missingEntry", json: `{"sources": [{"name": "roadmap", "url": "https://example.com/roadmap", "selectors": {"container": "main"}}]}`, expectError: true},
+		{testName: "badName", json: `{"sources": [{"name": "../roadmap", "url": "https://example.com/roadmap", "selectors": {"container": "main", "entry": "ul"}}]}`, expectError: true},
+		{testName: "duplicateName", json: `{"sources": [{"name": "a", "url": "https://example.com/a", "selectors": {"container": "main", "entry": "ul"}}, {"name": "a", "url": "https://example.com/b", "selectors": {"container": "main", "entry": "ul"}}]}`, expectError: true},
+		{testName: "unknownErrorPolicy", json: `{"sources": [{"name": "roadmap", "url": "https://example.com/roadmap", "errorPolicy": "ignore", "selectors": {"container": "main", "entry": "ul"}}]}`, expectError: true},
+		{testName: "page", json: `{"sources": [], "pages": [{"name": "drivers", "url": "https://example.com/drivers", "selector": "#releases"}]}`},
+		{testName: "pageBadSelector", json: `{"sources": [], "pages": [{"name": "drivers", "url": "https://example.com/drivers", "selector": "div["}]}`, expectError: true},
+		{testName: "pageBadURL", json: `{"sources": [], "pages": [{"name": "drivers", "url": "ftp://example.com/drivers"}]}`, expectError: true},
+		{testName: "pageUnknownErrorPolicy", json: `{"sources": [], "pages": [{"name": "drivers", "url": "https://example.com/drivers", "errorPolicy": "ignore"}]}`, expectError: true},
+		{testName: "pageDuplicateName", json: `{"sources": [], "pages": [{"name": "a", "url": "https://example.com/a"}, {"name": "a", "url": "https://example.com/b"}]}`, expectError: true},
+	}
+
+	for _, testData := range tests {
+		test.Run(testData.testName, func(test *testing.T) {
+
+			filePath := filepath.Join(test.TempDir(), "scrapeSources.json")
+			assert.NoError(test, os.WriteFile(filePath, []byte(testData.json), 0644))
+
+			config, err := ReadScrapeSources(filePath)
+			if testData.expectError {
+				assert.Error(test, err)
+				return
+			}
+
+			assert.NoError(test, err)
+			assert.Len(test, config.Sources, testData.expectedSources)
+		})
+	}
+
+	config, err := ReadScrapeSources(filepath.Join(test.TempDir(), "missing.json"))
+	assert.NoError(test, err)
+	assert.Empty(test, config.Sources)
+}
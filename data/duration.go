@@ -0,0 +1,87 @@
+package data
+
+import (
+	"fmt"
+	"github.com/json-iterator/go"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration A time.Duration that is read from and written as a string, ex: "36h", "30d" or "unlimited"
+type Duration time.Duration
+
+// Unlimited The longest Duration, written as "unlimited"
+const Unlimited = Duration(math.MaxInt64)
+
+// ParseDuration Same as time.ParseDuration, but also accepts whole days, ex: "30d"
+func ParseDuration(text string) (time.Duration, error) {
+
+	if strings.HasSuffix(text, "d") {
+
+		days, err := strconv.Atoi(strings.TrimSuffix(text, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", text, err)
+		}
+
+		return time.Duration(days) * time.Hour * 24, nil
+	}
+
+	return time.ParseDuration(text)
+}
+
+func (duration Duration) MarshalJSON() ([]byte, error) {
+
+	if duration == Unlimited {
+		return jsoniter.Marshal("unlimited")
+	}
+
+	return jsoniter.Marshal(duration.String())
+}
+
+// String Written the way it's usually typed in the config, ex: "30d" for whole days and "36h" instead of "36h0m0s"
+func (duration Duration) String() string {
+
+	day := time.Hour * 24
+
+	if duration > 0 && time.Duration(duration)%day == 0 {
+		return fmt.Sprintf("%dd", time.Duration(duration)/day)
+	}
+
+	text := time.Duration(duration).String()
+
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+
+	return text
+}
+
+func (duration *Duration) UnmarshalJSON(bytes []byte) error {
+
+	var text string
+
+	err := jsoniter.Unmarshal(bytes, &text)
+	if err != nil {
+		return fmt.Errorf("duration has to be a string: %w", err)
+	}
+
+	if text == "unlimited" {
+		*duration = Unlimited
+		return nil
+	}
+
+	parsed, err := ParseDuration(text)
+	if err != nil {
+		return err
+	}
+
+	*duration = Duration(parsed)
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KernelMoniker The kind of a kernel.org release
+type KernelMoniker string
+
+const (
+	Mainline  KernelMoniker = "mainline"
+	Stable    KernelMoniker = "stable"
+	Longterm  KernelMoniker = "longterm"
+	LinuxNext KernelMoniker = "linux-next"
+)
+
+// KernelMonikers The monikers in the order they're posted in
+var KernelMonikers = []KernelMoniker{Mainline, Stable, Longterm, LinuxNext}
+
+// KernelFilter Which kernel.org releases are posted, ex: only the 5.15 and 6.1 longterm branches
+type KernelFilter struct {
+	// Monikers The kinds of releases posted, all of them if empty
+	Monikers []KernelMoniker `json:"monikers,omitempty"`
+	// Branches The stable and longterm branches posted, ex: "6.1", all of them if empty.
+	// Mainline and linux-next releases aren't on a branch, so only Monikers decides if they're posted
+	Branches []string `json:"branches,omitempty"`
+}
+
+// Matches Whether a release with the moniker and version, ex: "6.1.12", is posted
+func (filter KernelFilter) Matches(moniker KernelMoniker, version string) bool {
+
+	if len(filter.Monikers) > 0 && !containsMoniker(filter.Monikers, moniker) {
+		return false
+	}
+
+	if len(filter.Branches) == 0 || (moniker != Stable && moniker != Longterm) {
+		return true
+	}
+
+	for _, branch := range filter.Branches {
+		if version == branch || strings.HasPrefix(version, branch+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (filter KernelFilter) validate() error {
+
+	for _, moniker := range filter.Monikers {
+		if !containsMoniker(KernelMonikers, moniker) {
+			return fmt.Errorf("unknown kernel moniker: %s", moniker)
+		}
+	}
+
+	for _, branch := range filter.Branches {
+		if _, ok := ParseVersion(branch); !ok {
+			return fmt.Errorf("invalid kernel branch: %s", branch)
+		}
+	}
+
+	return nil
+}
+
+func containsMoniker(monikers []KernelMoniker, moniker KernelMoniker) bool {
+
+	for _, value := range monikers {
+		if value == moniker {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,5 +1,12 @@
 package data
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
 type RSSType string
 
 const (
@@ -7,16 +14,196 @@ const (
 	Github           RSSType = "Github"
 	TitleAndLink     RSSType = "TitleAndLink"
 	KernelOrgUpdates RSSType = "KernelOrgUpdates"
+	// GithubReleases A repository's /releases.atom or /tags.atom feed
+	GithubReleases RSSType = "GithubReleases"
+)
+
+const (
+	defaultMaxAge    = time.Hour * 24
+	defaultSeenTTL   = time.Hour * 24 * 30
+	defaultSeenLimit = 2000
+)
+
+// ErrorPolicy What a module does when posting an update fails
+type ErrorPolicy string
+
+const (
+	// SkipOnError Logs the error and records the failed items as posted
+	SkipOnError ErrorPolicy = "skip"
+	// RetryOnError Logs the error and retries the failed items on the next check, the default
+	RetryOnError ErrorPolicy = "retry"
+	// DisableOnError Logs the error and disables the module
+	DisableOnError ErrorPolicy = "disable"
+)
+
+// validateErrorPolicy Checks that the policy, if set, is a known one
+func validateErrorPolicy(policy *ErrorPolicy) error {
+
+	if policy == nil {
+		return nil
+	}
+
+	switch *policy {
+	case SkipOnError, RetryOnError, DisableOnError:
+		return nil
+	}
+
+	return fmt.Errorf("unknown errorPolicy: %s", *policy)
+}
+
+// ParseColor Reads an embed color written in hex, with or without a leading "#", ex: "#00ff00"
+func ParseColor(color string) (int, error) {
+
+	value, err := strconv.ParseUint(strings.TrimPrefix(color, "#"), 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid color: %q", color)
+	}
+
+	return int(value), nil
+}
+
+// IdentityStrategy How items are told apart, to know which ones were already posted
+type IdentityStrategy string
+
+const (
+	// GUIDIdentity Uses the item's GUID, the default
+	GUIDIdentity  IdentityStrategy = "guid"
+	LinkIdentity  IdentityStrategy = "link"
+	TitleIdentity IdentityStrategy = "title"
+	// ContentHashIdentity Uses a hash of the normalized title and content
+	ContentHashIdentity IdentityStrategy = "hash"
+)
+
+// AgeField Which of an item's times decides whether it is recent enough to post
+type AgeField string
+
+const (
+	PublishedAge AgeField = "published"
+	UpdatedAge   AgeField = "updated"
+	// EitherAge Either time has to be recent
+	EitherAge AgeField = "either"
+	// BothAges Both times have to be recent, the default
+	BothAges AgeField = "both"
+)
+
+// SensitivePolicy How NSFW and spoiler posts are posted
+type SensitivePolicy string
+
+const (
+	ShowSensitive SensitivePolicy = "show"
+	// SpoilerSensitive Hides the post's title and link behind spoiler tags and leaves out its image, the default
+	SpoilerSensitive SensitivePolicy = "spoiler"
+	SkipSensitive    SensitivePolicy = "skip"
 )
 
 type RSSFeed struct {
-	ChannelName  string   `json:"channelName"`
-	FeedURL      string   `json:"feedURL"`
-	Color        *string  `json:"color,omitempty"`
-	Title        *string  `json:"title,omitempty"`
-	Description  *string  `json:"description,omitempty"`
-	ThumbnailURL *string  `json:"thumbnailURL,omitempty"`
-	Type         *RSSType `json:"type,omitempty"`
-	Author       *string  `json:"author,omitempty"`
-	FileName     *string  `json:"fileName,omitempty"`
+	ChannelName  string            `json:"channelName"`
+	FeedURL      string            `json:"feedURL"`
+	Color        *string           `json:"color,omitempty"`
+	Title        *string           `json:"title,omitempty"`
+	Description  *string           `json:"description,omitempty"`
+	ThumbnailURL *string           `json:"thumbnailURL,omitempty"`
+	Type         *RSSType          `json:"type,omitempty"`
+	Author       *string           `json:"author,omitempty"`
+	FileName     *string           `json:"fileName,omitempty"`
+	Paused       bool              `json:"paused,omitempty"`
+	ErrorPolicy  *ErrorPolicy      `json:"errorPolicy,omitempty"`
+	Identity     *IdentityStrategy `json:"identity,omitempty"`
+	SeenTTL      *Duration         `json:"seenTTL,omitempty"`
+	SeenLimit    *int              `json:"seenLimit,omitempty"`
+	MaxAge       *Duration         `json:"maxAge,omitempty"`
+	AgeField     *AgeField         `json:"ageField,omitempty"`
+	// Templates Replace parts of the formatted messages, see MessageTemplates
+	Templates *MessageTemplates `json:"templates,omitempty"`
+	// Transport The name of the transport profile to fetch the feed with
+	Transport *string `json:"transport,omitempty"`
+	// CatchUp Posts items older than the MaxAge as one digest instead of dropping them
+	CatchUp bool `json:"catchUp,omitempty"`
+	// Versions Only posts the items whose version matches, see VersionFilter
+	Versions *VersionFilter `json:"versions,omitempty"`
+	// Kernel Only posts the kernel.org releases it matches, for KernelOrgUpdates feeds
+	Kernel *KernelFilter `json:"kernel,omitempty"`
+	// NSFW and Spoilers How Reddit feeds post NSFW and spoiler posts
+	NSFW     *SensitivePolicy `json:"nsfw,omitempty"`
+	Spoilers *SensitivePolicy `json:"spoilers,omitempty"`
+}
+
+// GetErrorPolicy Returns the ErrorPolicy, defaulting to RetryOnError
+func (feed RSSFeed) GetErrorPolicy() ErrorPolicy {
+
+	if feed.ErrorPolicy == nil {
+		return RetryOnError
+	}
+
+	return *feed.ErrorPolicy
+}
+
+// GetIdentity Returns the IdentityStrategy, defaulting to GUIDIdentity
+func (feed RSSFeed) GetIdentity() IdentityStrategy {
+
+	if feed.Identity == nil {
+		return GUIDIdentity
+	}
+
+	return *feed.Identity
+}
+
+// GetSeenTTL How long an item is remembered after it was last in the feed, defaults to 30 days
+func (feed RSSFeed) GetSeenTTL() time.Duration {
+
+	if feed.SeenTTL == nil {
+		return defaultSeenTTL
+	}
+
+	return time.Duration(*feed.SeenTTL)
+}
+
+// GetSeenLimit How many items are remembered at most, defaults to 2000
+func (feed RSSFeed) GetSeenLimit() int {
+
+	if feed.SeenLimit == nil {
+		return defaultSeenLimit
+	}
+
+	return *feed.SeenLimit
+}
+
+// GetMaxAge How old an item can be and still be posted on its own, defaults to 24 hours
+func (feed RSSFeed) GetMaxAge() time.Duration {
+
+	if feed.MaxAge == nil {
+		return defaultMaxAge
+	}
+
+	return time.Duration(*feed.MaxAge)
+}
+
+// GetAgeField Returns the AgeField, defaulting to BothAges
+func (feed RSSFeed) GetAgeField() AgeField {
+
+	if feed.AgeField == nil {
+		return BothAges
+	}
+
+	return *feed.AgeField
+}
+
+// GetNSFW Returns the NSFW SensitivePolicy, defaulting to SpoilerSensitive
+func (feed RSSFeed) GetNSFW() SensitivePolicy {
+
+	if feed.NSFW == nil {
+		return SpoilerSensitive
+	}
+
+	return *feed.NSFW
+}
+
+// GetSpoilers Returns the spoiler SensitivePolicy, defaulting to SpoilerSensitive
+func (feed RSSFeed) GetSpoilers() SensitivePolicy {
+
+	if feed.Spoilers == nil {
+		return SpoilerSensitive
+	}
+
+	return *feed.Spoilers
 }
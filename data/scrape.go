@@ -0,0 +1,247 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andybalholm/cascadia"
+	"github.com/json-iterator/go"
+	"net/url"
+	"os"
+	"regexp"
+)
+
+// scrapeNamePattern Names are used for the state files, so they're kept to safe characters
+var scrapeNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// defaultDateFormats Used for sources without their own date formats
+var defaultDateFormats = []string{"2006-01-02", "2 January 2006", "2 Jan 2006", "January 2, 2006", "Jan 2, 2006"}
+
+// ScrapeSelectors The CSS selectors finding a page's entries.
+// The container's children are walked in order, each one matching Entry is an entry.
+// If there's a Heading, children matching it start a group and the entries after it take their date and title from it when they don't have their own
+type ScrapeSelectors struct {
+	Container string `json:"container"`
+	Entry     string `json:"entry"`
+	Heading   string `json:"heading,omitempty"`
+	// Skip Children that are ignored, ex: an archive section
+	Skip string `json:"skip,omitempty"`
+	// Title, Link and Date are looked for inside the entry, then inside the heading.
+	// Without a Date selector the heading's text is the date
+	Title string `json:"title,omitempty"`
+	Link  string `json:"link,omitempty"`
+	Date  string `json:"date,omitempty"`
+	// Lines The entry's changelog lines, ex: "li", the lines of its text if empty
+	Lines string `json:"lines,omitempty"`
+}
+
+// ScrapeSource A page without a feed whose entries are posted when new ones show up
+type ScrapeSource struct {
+	// Name Tells the source's state file and logs apart, ex: "longevityRoadmap"
+	Name        string          `json:"name"`
+	ChannelName string          `json:"channelName"`
+	URL         string          `json:"url"`
+	Selectors   ScrapeSelectors `json:"selectors"`
+	// DateFormats Go time layouts tried in order, ex: "2 January 2006"
+	DateFormats  []string     `json:"dateFormats,omitempty"`
+	Color        *string      `json:"color,omitempty"`
+	Description  *string      `json:"description,omitempty"`
+	ThumbnailURL *string      `json:"thumbnailURL,omitempty"`
+	ErrorPolicy  *ErrorPolicy `json:"errorPolicy,omitempty"`
+	// Strict Fails the check when the container has a child that isn't an entry, heading or skipped, since it usually means the page changed
+	Strict bool `json:"strict,omitempty"`
+	Paused bool `json:"paused,omitempty"`
+}
+
+// PageWatch A page without any structure, ex: a driver download page, whose text is posted as a diff when it changes
+type PageWatch struct {
+	// Name Tells the page's snapshot and logs apart, ex: "nvidiaDrivers"
+	Name        string `json:"name"`
+	ChannelName string `json:"channelName"`
+	URL         string `json:"url"`
+	// Selector The part of the page that's watched, the whole body if empty
+	Selector     string       `json:"selector,omitempty"`
+	Title        *string      `json:"title,omitempty"`
+	Color        *string      `json:"color,omitempty"`
+	ThumbnailURL *string      `json:"thumbnailURL,omitempty"`
+	ErrorPolicy  *ErrorPolicy `json:"errorPolicy,omitempty"`
+	Paused       bool         `json:"paused,omitempty"`
+}
+
+// ScrapeConfig The scrape sources file
+type ScrapeConfig struct {
+	Sources []ScrapeSource `json:"sources"`
+	Pages   []PageWatch    `json:"pages,omitempty"`
+}
+
+// GetErrorPolicy Returns the ErrorPolicy, defaulting to RetryOnError
+func (source ScrapeSource) GetErrorPolicy() ErrorPolicy {
+
+	if source.ErrorPolicy == nil {
+		return RetryOnError
+	}
+
+	return *source.ErrorPolicy
+}
+
+// GetErrorPolicy Returns the ErrorPolicy, defaulting to RetryOnError
+func (page PageWatch) GetErrorPolicy() ErrorPolicy {
+
+	if page.ErrorPolicy == nil {
+		return RetryOnError
+	}
+
+	return *page.ErrorPolicy
+}
+
+// GetDateFormats Returns the DateFormats, defaulting to ISO dates and the common English ones
+func (source ScrapeSource) GetDateFormats() []string {
+
+	if len(source.DateFormats) == 0 {
+		return defaultDateFormats
+	}
+
+	return source.DateFormats
+}
+
+func (source ScrapeSource) validate() error {
+
+	err := validateWatch(source.Name, source.URL, source.Color)
+	if err != nil {
+		return err
+	}
+
+	if err := validateErrorPolicy(source.ErrorPolicy); err != nil {
+		return err
+	}
+
+	if source.Selectors.Container == "" || source.Selectors.Entry == "" {
+		return fmt.Errorf("the container and entry selectors are required")
+	}
+
+	selectors := map[string]string{
+		"container": source.Selectors.Container,
+		"entry":     source.Selectors.Entry,
+		"heading":   source.Selectors.Heading,
+		"skip":      source.Selectors.Skip,
+		"title":     source.Selectors.Title,
+		"link":      source.Selectors.Link,
+		"date":      source.Selectors.Date,
+		"lines":     source.Selectors.Lines,
+	}
+
+	for name, selector := range selectors {
+		if selector == "" {
+			continue
+		}
+		if _, err := cascadia.Compile(selector); err != nil {
+			return fmt.Errorf("invalid %s selector %q: %w", name, selector, err)
+		}
+	}
+
+	return nil
+}
+
+func (page PageWatch) validate() error {
+
+	err := validateWatch(page.Name, page.URL, page.Color)
+	if err != nil {
+		return err
+	}
+
+	if err := validateErrorPolicy(page.ErrorPolicy); err != nil {
+		return err
+	}
+
+	if page.Selector != "" {
+		if _, err := cascadia.Compile(page.Selector); err != nil {
+			return fmt.Errorf("invalid selector %q: %w", page.Selector, err)
+		}
+	}
+
+	return nil
+}
+
+// validateWatch Checks what sources and pages have in common
+func validateWatch(name string, pageURL string, color *string) error {
+
+	if !scrapeNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid name: %q", name)
+	}
+
+	parsedURL, err := url.Parse(pageURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+		return fmt.Errorf("invalid url: %q", pageURL)
+	}
+
+	if color != nil {
+		if _, err := ParseColor(*color); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Validate Checks that every source and page has a unique name, a URL and selectors that parse
+func (config ScrapeConfig) Validate() error {
+
+	sourceNames := map[string]bool{}
+
+	for _, source := range config.Sources {
+
+		if err := source.validate(); err != nil {
+			return fmt.Errorf("scrape source %s: %w", source.Name, err)
+		}
+
+		if sourceNames[source.Name] {
+			return fmt.Errorf("scrape source %s: the name is used more than once", source.Name)
+		}
+
+		sourceNames[source.Name] = true
+	}
+
+	pageNames := map[string]bool{}
+
+	for _, page := range config.Pages {
+
+		if err := page.validate(); err != nil {
+			return fmt.Errorf("page %s: %w", page.Name, err)
+		}
+
+		if pageNames[page.Name] {
+			return fmt.Errorf("page %s: the name is used more than once", page.Name)
+		}
+
+		pageNames[page.Name] = true
+	}
+
+	return nil
+}
+
+// ReadScrapeSources Reads the scrape sources and watched pages file, a missing file means there are none
+func ReadScrapeSources(filePath string) (ScrapeConfig, error) {
+
+	sourcesJson, err := os.ReadFile(filePath)
+	if err != nil {
+
+		if errors.Is(err, os.ErrNotExist) {
+			return ScrapeConfig{}, nil
+		}
+
+		return ScrapeConfig{}, fmt.Errorf("failed to read scrapeSources: %w", err)
+	}
+
+	var config ScrapeConfig
+
+	err = jsoniter.Unmarshal(sourcesJson, &config)
+	if err != nil {
+		return ScrapeConfig{}, fmt.Errorf("failed to read scrapeSources: %w", err)
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return ScrapeConfig{}, fmt.Errorf("failed to read scrapeSources: %w", err)
+	}
+
+	return config, nil
+}
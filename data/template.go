@@ -0,0 +1,157 @@
+package data
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/mmcdole/gofeed"
+	"privateInfoBot/utils"
+	"text/template"
+	"time"
+)
+
+// MessageTemplates text/template definitions that replace parts of a feed's messages, empty ones are left as formatted
+type MessageTemplates struct {
+	Content     string          `json:"content,omitempty"`
+	Title       string          `json:"title,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Footer      string          `json:"footer,omitempty"`
+	Fields      []FieldTemplate `json:"fields,omitempty"`
+}
+
+type FieldTemplate struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+// TemplateData What templates can use, Item is the first of Items for types that post items together
+type TemplateData struct {
+	Feed  RSSFeed
+	Item  *gofeed.Item
+	Items []*gofeed.Item
+}
+
+// ParsedTemplates MessageTemplates that are ready to execute, nil templates weren't defined
+type ParsedTemplates struct {
+	Content     *template.Template
+	Title       *template.Template
+	Description *template.Template
+	Footer      *template.Template
+	Fields      []ParsedFieldTemplate
+}
+
+type ParsedFieldTemplate struct {
+	Name   *template.Template
+	Value  *template.Template
+	Inline bool
+}
+
+// Parse Parses every template, so mistakes are found when the config is loaded
+func (templates MessageTemplates) Parse() (*ParsedTemplates, error) {
+
+	var parsed ParsedTemplates
+	var err error
+
+	for _, definition := range []struct {
+		name   string
+		source string
+		result **template.Template
+	}{
+		{"content", templates.Content, &parsed.Content},
+		{"title", templates.Title, &parsed.Title},
+		{"description", templates.Description, &parsed.Description},
+		{"footer", templates.Footer, &parsed.Footer},
+	} {
+		*definition.result, err = parseTemplate(definition.name, definition.source)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, field := range templates.Fields {
+
+		// Discord rejects fields without both
+		if field.Name == "" || field.Value == "" {
+			return nil, fmt.Errorf("invalid template: fields[%d] needs a name and a value", i)
+		}
+
+		name, err := parseTemplate(fmt.Sprintf("fields[%d].name", i), field.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := parseTemplate(fmt.Sprintf("fields[%d].value", i), field.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		parsed.Fields = append(parsed.Fields, ParsedFieldTemplate{Name: name, Value: value, Inline: field.Inline})
+	}
+
+	err = parsed.check()
+	if err != nil {
+		return nil, err
+	}
+
+	return &parsed, nil
+}
+
+// check Executes every template with a sample item, since misspelled fields are only found when executing
+func (parsed *ParsedTemplates) check() error {
+
+	now := time.Now()
+
+	item := &gofeed.Item{
+		PublishedParsed: &now,
+		UpdatedParsed:   &now,
+		Image:           &gofeed.Image{},
+		Authors:         []*gofeed.Person{{}},
+		Categories:      []string{""},
+		Enclosures:      []*gofeed.Enclosure{{}},
+	}
+
+	sample := TemplateData{Item: item, Items: []*gofeed.Item{item}}
+
+	templates := []*template.Template{parsed.Content, parsed.Title, parsed.Description, parsed.Footer}
+	for _, field := range parsed.Fields {
+		templates = append(templates, field.Name, field.Value)
+	}
+
+	for _, parsedTemplate := range templates {
+		if parsedTemplate != nil {
+			if _, err := ExecuteTemplate(parsedTemplate, sample); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseTemplate Returns nil for an empty source
+func parseTemplate(name string, source string) (*template.Template, error) {
+
+	if source == "" {
+		return nil, nil
+	}
+
+	parsed, err := template.New(name).Funcs(utils.TemplateFuncs()).Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+
+	return parsed, nil
+}
+
+// ExecuteTemplate Executes the template into a string
+func ExecuteTemplate(parsed *template.Template, data TemplateData) (string, error) {
+
+	var buffer bytes.Buffer
+
+	err := parsed.Execute(&buffer, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	return buffer.String(), nil
+}
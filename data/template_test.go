@@ -0,0 +1,66 @@
+package data
+
+import (
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMessageTemplates_Parse(test *testing.T) {
+
+	tests := []struct {
+		testName    string
+		templates   MessageTemplates
+		expectError bool
+	}{
+		{testName: "valid", templates: MessageTemplates{Title: "{{ .Item.Title | truncate 50 }}", Footer: `{{ date "02 Jan 2006" .Item.PublishedParsed }}`}},
+		{testName: "authors", templates: MessageTemplates{Description: "By {{ (index .Item.Authors 0).Name }}"}},
+		{testName: "syntax", templates: MessageTemplates{Title: "{{ .Item.Title "}, expectError: true},
+		{testName: "misspelledField", templates: MessageTemplates{Title: "{{ .Item.Titel }}"}, expectError: true},
+		{testName: "unknownFunction", templates: MessageTemplates{Title: "{{ shout .Item.Title }}"}, expectError: true},
+		{testName: "fieldWithoutValue", templates: MessageTemplates{Fields: []FieldTemplate{{Name: "Link"}}}, expectError: true},
+	}
+
+	for _, testData := range tests {
+		test.Run(testData.testName, func(test *testing.T) {
+
+			_, err := testData.templates.Parse()
+			if testData.expectError {
+				assert.Error(test, err)
+			} else {
+				assert.NoError(test, err)
+			}
+		})
+	}
+}
+
+func TestExecuteTemplate(test *testing.T) {
+
+	published := time.Date(2022, time.October, 1, 12, 0, 0, 0, time.UTC)
+	title := "Example"
+
+	parsed, err := MessageTemplates{
+		Title:       "{{ .Feed.Title }}: {{ .Item.Title | truncate 10 }}",
+		Description: "{{ .Item.Description | markdown }}",
+		Footer:      `{{ date "02 Jan 2006" .Item.PublishedParsed }}`,
+	}.Parse()
+	assert.NoError(test, err)
+
+	templateData := TemplateData{
+		Feed: RSSFeed{Title: &title},
+		Item: &gofeed.Item{Title: "A rather long title", Description: "<p>Some &amp; text</p>", PublishedParsed: &published},
+	}
+
+	result, err := ExecuteTemplate(parsed.Title, templateData)
+	assert.NoError(test, err)
+	assert.Equal(test, "Example: A rather …", result)
+
+	result, err = ExecuteTemplate(parsed.Description, templateData)
+	assert.NoError(test, err)
+	assert.Equal(test, "Some & text", result)
+
+	result, err = ExecuteTemplate(parsed.Footer, templateData)
+	assert.NoError(test, err)
+	assert.Equal(test, "01 Oct 2022", result)
+}
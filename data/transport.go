@@ -0,0 +1,58 @@
+package data
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// TransportProfile How a feed's site is fetched, for sites that need workarounds, ex: Reddit
+type TransportProfile struct {
+	UserAgent    string            `json:"userAgent,omitempty"`
+	DisableHTTP2 bool              `json:"disableHTTP2,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	// Proxy The URL of the proxy to fetch through, ex: "http://localhost:8080"
+	Proxy string `json:"proxy,omitempty"`
+	// BodyReplacements Repairs the body before it is parsed, applied in order
+	BodyReplacements []BodyReplacement `json:"bodyReplacements,omitempty"`
+}
+
+type BodyReplacement struct {
+	Find    string `json:"find"`
+	Replace string `json:"replace"`
+	// Count How many occurrences are replaced, all of them if zero
+	Count int `json:"count,omitempty"`
+}
+
+// RepairBody Applies the body replacements
+func (profile TransportProfile) RepairBody(body string) string {
+
+	for _, replacement := range profile.BodyReplacements {
+
+		count := replacement.Count
+		if count == 0 {
+			count = -1
+		}
+
+		body = strings.Replace(body, replacement.Find, replacement.Replace, count)
+	}
+
+	return body
+}
+
+func (profile TransportProfile) validate() error {
+
+	if profile.Proxy != "" {
+		if _, err := url.Parse(profile.Proxy); err != nil {
+			return fmt.Errorf("invalid proxy: %w", err)
+		}
+	}
+
+	for _, replacement := range profile.BodyReplacements {
+		if replacement.Find == "" {
+			return fmt.Errorf("body replacement without find")
+		}
+	}
+
+	return nil
+}
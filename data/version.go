@@ -0,0 +1,168 @@
+package data
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	versionPattern   = regexp.MustCompile(`(?:^|[^0-9A-Za-z.])[vV]?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z.-]+))?`)
+	conditionPattern = regexp.MustCompile(`^(>=|<=|!=|>|<|=)?\s*[vV]?(\d+(?:\.\d+){0,2}(?:-[0-9A-Za-z.-]+)?)$`)
+)
+
+// Version A semantic version, parts that are missing are 0
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+}
+
+// ParseVersion Finds the first version in the text, ex: "v6.0.2", "Release 6.1-rc3" or "6.0.2: stable"
+func ParseVersion(text string) (Version, bool) {
+
+	match := versionPattern.FindStringSubmatch(text)
+	if match == nil {
+		return Version{}, false
+	}
+
+	var version Version
+	version.Major, _ = strconv.Atoi(match[1])
+	version.Minor, _ = strconv.Atoi(match[2])
+	version.Patch, _ = strconv.Atoi(match[3])
+	version.Prerelease = match[4]
+
+	return version, true
+}
+
+func (version Version) String() string {
+
+	text := fmt.Sprintf("%d.%d.%d", version.Major, version.Minor, version.Patch)
+	if version.Prerelease != "" {
+		text += "-" + version.Prerelease
+	}
+
+	return text
+}
+
+// IsPrerelease Whether the version has a pre-release suffix, ex: 6.1.0-rc3
+func (version Version) IsPrerelease() bool {
+	return version.Prerelease != ""
+}
+
+// Compare Returns -1, 0 or 1 when the version is lower, equal or higher than the other, a pre-release is lower than its release
+func (version Version) Compare(other Version) int {
+
+	parts := [][2]int{{version.Major, other.Major}, {version.Minor, other.Minor}, {version.Patch, other.Patch}}
+	for _, part := range parts {
+		if part[0] != part[1] {
+			if part[0] < part[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case version.Prerelease == other.Prerelease:
+		return 0
+	case version.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	case version.Prerelease < other.Prerelease:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// VersionFilter Which versions of a release feed are posted, comma separated conditions that all have to match.
+// A condition is "major" (x.0.0), "minor" (x.y.0), "stable" (no pre-releases) or a comparison, ex: ">= 6.0", "< 7", "!= 6.0.1"
+type VersionFilter string
+
+// ParsedVersionFilter A VersionFilter ready to be matched against
+type ParsedVersionFilter struct {
+	conditions []versionCondition
+}
+
+type versionCondition func(version Version) bool
+
+// Parse Parses the conditions, failing on ones it doesn't know
+func (filter VersionFilter) Parse() (*ParsedVersionFilter, error) {
+
+	parsed := &ParsedVersionFilter{}
+
+	for _, term := range strings.Split(string(filter), ",") {
+
+		term = strings.TrimSpace(term)
+
+		condition, err := parseVersionCondition(term)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version filter %q: %w", filter, err)
+		}
+
+		parsed.conditions = append(parsed.conditions, condition)
+	}
+
+	return parsed, nil
+}
+
+func parseVersionCondition(term string) (versionCondition, error) {
+
+	switch strings.ToLower(term) {
+	case "major":
+		return func(version Version) bool { return version.Minor == 0 && version.Patch == 0 }, nil
+	case "minor":
+		return func(version Version) bool { return version.Patch == 0 }, nil
+	case "stable":
+		return func(version Version) bool { return !version.IsPrerelease() }, nil
+	}
+
+	match := conditionPattern.FindStringSubmatch(term)
+	if match == nil {
+		return nil, fmt.Errorf("unknown condition: %q", term)
+	}
+
+	operator := match[1]
+	bound, _ := ParseVersion(match[2])
+
+	return func(version Version) bool {
+
+		comparison := version.Compare(bound)
+
+		switch operator {
+		case ">=":
+			return comparison >= 0
+		case "<=":
+			return comparison <= 0
+		case ">":
+			return comparison > 0
+		case "<":
+			return comparison < 0
+		case "!=":
+			return comparison != 0
+		default:
+			return comparison == 0
+		}
+	}, nil
+}
+
+// Matches Whether the text has a version and it matches every condition
+func (filter *ParsedVersionFilter) Matches(text string) bool {
+
+	version, ok := ParseVersion(text)
+	if !ok {
+		return false
+	}
+
+	for _, condition := range filter.conditions {
+		if !condition(version) {
+			return false
+		}
+	}
+
+	return true
+}
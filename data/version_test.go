@@ -0,0 +1,80 @@
+package data
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseVersion(test *testing.T) {
+
+	tests := []struct {
+		text     string
+		expected Version
+		ok       bool
+	}{
+		{text: "v6.0.2", expected: Version{Major: 6, Patch: 2}, ok: true},
+		{text: "6.1-rc3", expected: Version{Major: 6, Minor: 1, Prerelease: "rc3"}, ok: true},
+		{text: "linux-tkg 6.0.2", expected: Version{Major: 6, Patch: 2}, ok: true},
+		{text: "6.0.2: stable", expected: Version{Major: 6, Patch: 2}, ok: true},
+		{text: "nightly", ok: false},
+	}
+
+	for _, testData := range tests {
+		test.Run(testData.text, func(test *testing.T) {
+
+			version, ok := ParseVersion(testData.text)
+			assert.Equal(test, testData.ok, ok)
+			assert.Equal(test, testData.expected, version)
+		})
+	}
+}
+
+func TestVersionFilter(test *testing.T) {
+
+	tests := []struct {
+		filter     VersionFilter
+		matches    []string
+		notMatches []string
+	}{
+		{filter: "major", matches: []string{"v6.0.0", "7"}, notMatches: []string{"v6.1.0", "v6.0.2", "nightly"}},
+		{filter: "minor", matches: []string{"v6.1.0", "v7.0"}, notMatches: []string{"v6.1.2"}},
+		{filter: ">= 6.0", matches: []string{"v6.0.0", "v6.0.2", "v10.1"}, notMatches: []string{"v5.19.17", "v6.0.0-rc1"}},
+		{filter: "< 7", matches: []string{"v6.9.9", "v7.0.0-rc1"}, notMatches: []string{"v7.0.0"}},
+		{filter: "stable, >=6.0, != 6.0.1", matches: []string{"v6.0.2"}, notMatches: []string{"v6.0.1", "v6.1.0-rc1"}},
+	}
+
+	for _, testData := range tests {
+		test.Run(string(testData.filter), func(test *testing.T) {
+
+			parsed, err := testData.filter.Parse()
+			assert.NoError(test, err)
+
+			for _, text := range testData.matches {
+				assert.True(test, parsed.Matches(text), text)
+			}
+
+			for _, text := range testData.notMatches {
+				assert.False(test, parsed.Matches(text), text)
+			}
+		})
+	}
+
+	_, err := VersionFilter("only majors please").Parse()
+	assert.Error(test, err)
+}
+
+func TestKernelFilter_Matches(test *testing.T) {
+
+	filter := KernelFilter{Monikers: []KernelMoniker{Mainline, Longterm}, Branches: []string{"5.15", "6.1"}}
+
+	assert.True(test, filter.Matches(Longterm, "5.15.71"))
+	assert.True(test, filter.Matches(Longterm, "6.1"))
+	assert.False(test, filter.Matches(Longterm, "5.10.146"))
+	assert.False(test, filter.Matches(Longterm, "5.155.1"))
+	assert.True(test, filter.Matches(Mainline, "6.2-rc1"), "mainline isn't on a branch")
+	assert.False(test, filter.Matches(Stable, "6.1.12"))
+	assert.False(test, filter.Matches(LinuxNext, "next-20221004"))
+
+	assert.True(test, KernelFilter{}.Matches(LinuxNext, "next-20221004"))
+	assert.Error(test, KernelFilter{Monikers: []KernelMoniker{"nightly"}}.validate())
+}
@@ -0,0 +1,444 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"log"
+	"math/rand"
+	"net/http"
+	"path"
+	"privateInfoBot/utils"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxAttempts = 8
+	defaultBaseDelay   = time.Second * 5
+	defaultMaxDelay    = time.Hour
+	// maxDeadLetters How many dead letters are kept, older ones are dropped
+	maxDeadLetters = 100
+	// idleDelay How long Run waits when nothing is queued, Enqueue wakes it up earlier
+	idleDelay = time.Minute
+)
+
+// Sender The parts of discordgo.Session used to deliver messages
+type Sender interface {
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend) (*discordgo.Message, error)
+	ChannelMessageCrosspost(channelID, messageID string) (*discordgo.Message, error)
+}
+
+type Delivery struct {
+	ID        string                 `json:"id"`
+	ChannelID string                 `json:"channelID"`
+	Message   *discordgo.MessageSend `json:"message"`
+	Crosspost bool                   `json:"crosspost"`
+	// MessageID is set once the message is sent, so only the crosspost is retried
+	MessageID   string    `json:"messageID,omitempty"`
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"nextAttempt"`
+	LastError   string    `json:"lastError,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	// Source Who queued it, ex: a module's state key, so a delivery Discord rejects is reported back to it
+	Source string `json:"source,omitempty"`
+}
+
+// Queue A durable queue of messages to send, retrying failed sends with exponential backoff
+type Queue struct {
+	mutex       sync.Mutex
+	directory   string
+	sender      Sender
+	pending     []*Delivery
+	deadLetters []*Delivery
+	nextID      uint64
+	wake        chan struct{}
+	// rejected The last error Discord rejected each source's deliveries with for good, until TakeRejection
+	rejected map[string]error
+
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// NewQueue Loads the queue and dead letters saved in the directory
+func NewQueue(directory string, sender Sender) (*Queue, error) {
+
+	queue := &Queue{
+		directory:   directory,
+		sender:      sender,
+		wake:        make(chan struct{}, 1),
+		rejected:    map[string]error{},
+		MaxAttempts: defaultMaxAttempts,
+		BaseDelay:   defaultBaseDelay,
+		MaxDelay:    defaultMaxDelay,
+	}
+
+	_, err := utils.ReadJson(queue.pendingFilePath(), &queue.pending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load delivery queue: %w", err)
+	}
+
+	_, err = utils.ReadJson(queue.deadLettersFilePath(), &queue.deadLetters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dead letters: %w", err)
+	}
+
+	return queue, nil
+}
+
+// Enqueue Queues the source's messages for the channel in order and saves the queue
+func (queue *Queue) Enqueue(source string, channelID string, messages []discordgo.MessageSend, crosspost bool) error {
+
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	now := time.Now()
+
+	for _, message := range messages {
+
+		message := message
+
+		// Embed isn't serialized, so it would be lost when saving
+		if message.Embed != nil {
+			message.Embeds = append(message.Embeds, message.Embed)
+			message.Embed = nil
+		}
+
+		queue.nextID++
+
+		queue.pending = append(queue.pending, &Delivery{
+			ID:          strconv.FormatInt(now.UnixNano(), 36) + "-" + strconv.FormatUint(queue.nextID, 36),
+			Source:      source,
+			ChannelID:   channelID,
+			Message:     &message,
+			Crosspost:   crosspost,
+			NextAttempt: now,
+			CreatedAt:   now,
+		})
+	}
+
+	err := queue.savePending()
+	if err != nil {
+		// Not queued if it can't be saved, so the caller can retry
+		queue.pending = queue.pending[:len(queue.pending)-len(messages)]
+		return err
+	}
+
+	select {
+	case queue.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Run Delivers queued messages as they become due until the context is cancelled
+func (queue *Queue) Run(ctx context.Context) {
+
+	for {
+
+		queue.ProcessDue(ctx)
+
+		timer := time.NewTimer(queue.untilNextAttempt())
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-queue.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// ProcessDue Attempts every delivery that is due, keeping the order of deliveries within a channel
+func (queue *Queue) ProcessDue(ctx context.Context) {
+
+	now := time.Now()
+
+	queue.mutex.Lock()
+
+	var due []*Delivery
+	blockedChannels := map[string]bool{}
+
+	for _, delivery := range queue.pending {
+
+		if blockedChannels[delivery.ChannelID] {
+			continue
+		}
+
+		if delivery.NextAttempt.After(now) {
+			// Later deliveries to the channel wait for this one
+			blockedChannels[delivery.ChannelID] = true
+			continue
+		}
+
+		due = append(due, delivery)
+	}
+
+	queue.mutex.Unlock()
+
+	failedChannels := map[string]bool{}
+
+	for _, delivery := range due {
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		if failedChannels[delivery.ChannelID] {
+			continue
+		}
+
+		err := queue.attempt(delivery)
+
+		queue.mutex.Lock()
+
+		if err != nil {
+			failedChannels[delivery.ChannelID] = true
+			queue.handleFailure(delivery, err)
+		} else {
+			queue.remove(delivery)
+		}
+
+		saveErr := queue.savePending()
+
+		queue.mutex.Unlock()
+
+		if saveErr != nil {
+			log.Printf("%v", saveErr)
+		}
+	}
+}
+
+// attempt Sends the message if it hasn't been sent yet, then crossposts it
+func (queue *Queue) attempt(delivery *Delivery) error {
+
+	if delivery.MessageID == "" {
+
+		message, err := queue.sender.ChannelMessageSendComplex(delivery.ChannelID, delivery.Message)
+		if err != nil {
+			return fmt.Errorf("failed to send message to channel (%s): %w", delivery.ChannelID, err)
+		}
+
+		queue.mutex.Lock()
+
+		delivery.MessageID = message.ID
+
+		// Saved right away so a crash before the crosspost doesn't send it again
+		err = queue.savePending()
+
+		queue.mutex.Unlock()
+
+		if err != nil {
+			log.Printf("%v", err)
+		}
+	}
+
+	if delivery.Crosspost {
+
+		_, err := queue.sender.ChannelMessageCrosspost(delivery.ChannelID, delivery.MessageID)
+		if err != nil {
+
+			// Ex: The channel isn't an announcement channel, the message was still delivered
+			if !isTransient(err) {
+				log.Printf("%v", fmt.Errorf("giving up on crosspost to channel (%s): %w", delivery.ChannelID, err))
+				return nil
+			}
+
+			return fmt.Errorf("failed to crosspost message to channel (%s): %w", delivery.ChannelID, err)
+		}
+	}
+
+	return nil
+}
+
+// handleFailure Schedules the next attempt, or moves the delivery to the dead letters, requires the lock
+func (queue *Queue) handleFailure(delivery *Delivery, err error) {
+
+	delivery.Attempts++
+	delivery.LastError = err.Error()
+
+	if !isTransient(err) || delivery.Attempts >= queue.MaxAttempts {
+
+		log.Printf("%v", fmt.Errorf("moving delivery %s to dead letters after %d attempts: %w", delivery.ID, delivery.Attempts, err))
+
+		// Ex: the channel was deleted or the bot lost access, retrying won't help, the source's error policy decides what to do
+		if !isTransient(err) && delivery.Source != "" {
+			queue.rejected[delivery.Source] = err
+		}
+
+		queue.remove(delivery)
+		queue.deadLetters = append(queue.deadLetters, delivery)
+
+		if len(queue.deadLetters) > maxDeadLetters {
+			queue.deadLetters = queue.deadLetters[len(queue.deadLetters)-maxDeadLetters:]
+		}
+
+		saveErr := utils.WriteJsonAfterMakeDirs(queue.deadLettersFilePath(), queue.deadLetters)
+		if saveErr != nil {
+			log.Printf("%v", fmt.Errorf("failed to save dead letters: %w", saveErr))
+		}
+
+		return
+	}
+
+	delivery.NextAttempt = time.Now().Add(queue.backoff(delivery.Attempts))
+	log.Printf("%v", fmt.Errorf("retrying delivery %s at %v: %w", delivery.ID, delivery.NextAttempt.Format(time.Kitchen), err))
+}
+
+// backoff The exponential delay before the next attempt, with the upper half jittered
+func (queue *Queue) backoff(attempts int) time.Duration {
+
+	delay := queue.BaseDelay
+	for i := 1; i < attempts && delay < queue.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > queue.MaxDelay {
+		delay = queue.MaxDelay
+	}
+
+	half := delay / 2
+
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// remove Removes the delivery from pending, requires the lock
+func (queue *Queue) remove(delivery *Delivery) {
+	for i, pending := range queue.pending {
+		if pending == delivery {
+			queue.pending = append(queue.pending[:i], queue.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+func (queue *Queue) untilNextAttempt() time.Duration {
+
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	wait := idleDelay
+
+	for _, delivery := range queue.pending {
+		if until := time.Until(delivery.NextAttempt); until < wait {
+			wait = until
+		}
+	}
+
+	if wait < 0 {
+		return 0
+	}
+
+	return wait
+}
+
+func (queue *Queue) Pending() []Delivery {
+
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	return copyDeliveries(queue.pending)
+}
+
+func (queue *Queue) DeadLetters() []Delivery {
+
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	return copyDeliveries(queue.deadLetters)
+}
+
+// TakeRejection Returns the last error Discord rejected the source's deliveries with for good, nil if it didn't, and forgets it
+func (queue *Queue) TakeRejection(source string) error {
+
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	err := queue.rejected[source]
+	delete(queue.rejected, source)
+
+	return err
+}
+
+// RetryDeadLetters Moves every dead letter back into the queue, returning how many were moved
+func (queue *Queue) RetryDeadLetters() (int, error) {
+
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	now := time.Now()
+	count := len(queue.deadLetters)
+
+	for _, delivery := range queue.deadLetters {
+		delivery.Attempts = 0
+		delivery.NextAttempt = now
+		queue.pending = append(queue.pending, delivery)
+	}
+
+	queue.deadLetters = nil
+
+	err := queue.savePending()
+	if err != nil {
+		return 0, err
+	}
+
+	err = utils.WriteJsonAfterMakeDirs(queue.deadLettersFilePath(), queue.deadLetters)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save dead letters: %w", err)
+	}
+
+	select {
+	case queue.wake <- struct{}{}:
+	default:
+	}
+
+	return count, nil
+}
+
+// savePending Saves the pending deliveries, requires the lock
+func (queue *Queue) savePending() error {
+
+	err := utils.WriteJsonAfterMakeDirs(queue.pendingFilePath(), queue.pending)
+	if err != nil {
+		return fmt.Errorf("failed to save delivery queue: %w", err)
+	}
+
+	return nil
+}
+
+func (queue *Queue) pendingFilePath() string {
+	return path.Join(queue.directory, "queue.json")
+}
+
+func (queue *Queue) deadLettersFilePath() string {
+	return path.Join(queue.directory, "dead_letters.json")
+}
+
+// isTransient Rate limits, server errors and errors without a response, ex: timeouts, are worth retrying
+func isTransient(err error) bool {
+
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) || restErr.Response == nil {
+		return true
+	}
+
+	statusCode := restErr.Response.StatusCode
+
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+func copyDeliveries(deliveries []*Delivery) []Delivery {
+
+	result := make([]Delivery, len(deliveries))
+	for i, delivery := range deliveries {
+		result[i] = *delivery
+	}
+
+	return result
+}
@@ -0,0 +1,191 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type fakeSender struct {
+	sent            []string
+	crossposted     []string
+	sendErrors      []error
+	crosspostErrors []error
+}
+
+func (sender *fakeSender) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend) (*discordgo.Message, error) {
+
+	if len(sender.sendErrors) > 0 {
+		err := sender.sendErrors[0]
+		sender.sendErrors = sender.sendErrors[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sender.sent = append(sender.sent, data.Content)
+
+	return &discordgo.Message{ID: strconv.Itoa(len(sender.sent))}, nil
+}
+
+func (sender *fakeSender) ChannelMessageCrosspost(channelID, messageID string) (*discordgo.Message, error) {
+
+	if len(sender.crosspostErrors) > 0 {
+		err := sender.crosspostErrors[0]
+		sender.crosspostErrors = sender.crosspostErrors[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sender.crossposted = append(sender.crossposted, messageID)
+
+	return &discordgo.Message{ID: messageID}, nil
+}
+
+func restError(statusCode int) error {
+	return &discordgo.RESTError{Response: &http.Response{StatusCode: statusCode}}
+}
+
+func newTestQueue(test *testing.T, sender Sender) *Queue {
+
+	queue, err := NewQueue(test.TempDir(), sender)
+	assert.NoError(test, err)
+
+	queue.BaseDelay = 0
+	queue.MaxDelay = 0
+
+	return queue
+}
+
+func messages(contents ...string) []discordgo.MessageSend {
+
+	var result []discordgo.MessageSend
+	for _, content := range contents {
+		result = append(result, discordgo.MessageSend{Content: content})
+	}
+
+	return result
+}
+
+func TestQueue_delivers(test *testing.T) {
+
+	sender := &fakeSender{}
+	queue := newTestQueue(test, sender)
+
+	assert.NoError(test, queue.Enqueue("RSS/feed", "1", messages("first", "second"), true))
+	queue.ProcessDue(context.Background())
+
+	assert.Equal(test, []string{"first", "second"}, sender.sent)
+	assert.Equal(test, []string{"1", "2"}, sender.crossposted)
+	assert.Empty(test, queue.Pending())
+}
+
+func TestQueue_retriesTransientErrors(test *testing.T) {
+
+	sender := &fakeSender{sendErrors: []error{restError(http.StatusTooManyRequests), restError(http.StatusBadGateway)}}
+	queue := newTestQueue(test, sender)
+
+	assert.NoError(test, queue.Enqueue("RSS/feed", "1", messages("first", "second"), false))
+
+	queue.ProcessDue(context.Background())
+	assert.Empty(test, sender.sent)
+
+	// The failed delivery holds back the rest of the channel to keep the order
+	pending := queue.Pending()
+	assert.Len(test, pending, 2)
+	assert.Equal(test, 1, pending[0].Attempts)
+
+	queue.ProcessDue(context.Background())
+	queue.ProcessDue(context.Background())
+
+	assert.Equal(test, []string{"first", "second"}, sender.sent)
+	assert.Empty(test, queue.Pending())
+	assert.Empty(test, queue.DeadLetters())
+}
+
+func TestQueue_crosspostFailureDoesNotResend(test *testing.T) {
+
+	sender := &fakeSender{crosspostErrors: []error{restError(http.StatusInternalServerError)}}
+	queue := newTestQueue(test, sender)
+
+	assert.NoError(test, queue.Enqueue("RSS/feed", "1", messages("first"), true))
+
+	queue.ProcessDue(context.Background())
+	assert.Equal(test, []string{"first"}, sender.sent)
+	assert.Empty(test, sender.crossposted)
+	assert.Equal(test, "1", queue.Pending()[0].MessageID)
+
+	queue.ProcessDue(context.Background())
+	assert.Equal(test, []string{"first"}, sender.sent)
+	assert.Equal(test, []string{"1"}, sender.crossposted)
+	assert.Empty(test, queue.Pending())
+}
+
+func TestQueue_deadLetters(test *testing.T) {
+
+	sender := &fakeSender{sendErrors: []error{restError(http.StatusForbidden), errors.New("timeout"), errors.New("timeout")}}
+	queue := newTestQueue(test, sender)
+	queue.MaxAttempts = 2
+
+	assert.NoError(test, queue.Enqueue("RSS/forbidden", "1", messages("forbidden"), false))
+	assert.NoError(test, queue.Enqueue("RSS/timeout", "2", messages("timeout"), false))
+
+	queue.ProcessDue(context.Background())
+	queue.ProcessDue(context.Background())
+
+	deadLetters := queue.DeadLetters()
+	assert.Len(test, deadLetters, 2)
+	assert.Equal(test, 1, deadLetters[0].Attempts, "permanent errors shouldn't be retried")
+	assert.Equal(test, 2, deadLetters[1].Attempts)
+	assert.Empty(test, queue.Pending())
+
+	// Only the permanent error is reported back to its source, once
+	assert.Error(test, queue.TakeRejection("RSS/forbidden"))
+	assert.NoError(test, queue.TakeRejection("RSS/forbidden"))
+	assert.NoError(test, queue.TakeRejection("RSS/timeout"))
+
+	// Survives a restart
+	reloaded, err := NewQueue(queue.directory, sender)
+	assert.NoError(test, err)
+	assert.Len(test, reloaded.DeadLetters(), 2)
+
+	count, err := reloaded.RetryDeadLetters()
+	assert.NoError(test, err)
+	assert.Equal(test, 2, count)
+
+	reloaded.ProcessDue(context.Background())
+	assert.Equal(test, []string{"forbidden", "timeout"}, sender.sent)
+	assert.Empty(test, reloaded.DeadLetters())
+}
+
+func TestQueue_persistsPending(test *testing.T) {
+
+	queue := newTestQueue(test, &fakeSender{})
+
+	embed := &discordgo.MessageEmbed{Title: "Kernel updates found!"}
+	assert.NoError(test, queue.Enqueue("RSS/feed", "1", []discordgo.MessageSend{{Embed: embed}}, true))
+
+	reloaded, err := NewQueue(queue.directory, &fakeSender{})
+	assert.NoError(test, err)
+
+	pending := reloaded.Pending()
+	assert.Len(test, pending, 1)
+	assert.Equal(test, "Kernel updates found!", pending[0].Message.Embeds[0].Title)
+}
+
+func TestQueue_backoff(test *testing.T) {
+
+	queue := &Queue{BaseDelay: time.Second, MaxDelay: time.Minute}
+
+	for attempts, expected := range map[int]time.Duration{1: time.Second, 3: time.Second * 4, 20: time.Minute} {
+		delay := queue.backoff(attempts)
+		assert.GreaterOrEqual(test, delay, expected/2)
+		assert.LessOrEqual(test, delay, expected)
+	}
+}
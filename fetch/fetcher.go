@@ -0,0 +1,302 @@
+package fetch
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTimeout     = time.Second * 30
+	defaultMaxPerHost  = 2
+	defaultMinInterval = time.Second * 2
+	// maxBodySize Feeds bigger than this are cut off
+	maxBodySize = 16 << 20
+)
+
+// Validators What the server sent to identify the content, sent back so it can reply 304 Not Modified
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// Options How a single request is made
+type Options struct {
+	Header http.Header
+	// DisableHTTP2 Ex: Reddit answers 429 to Go's HTTP/2 client
+	DisableHTTP2 bool
+	// Proxy The proxy to fetch through, the environment's proxy if empty
+	Proxy string
+}
+
+// clientKey The options that need their own http.Client
+type clientKey struct {
+	disableHTTP2 bool
+	proxy        string
+}
+
+type Response struct {
+	// NotModified The content didn't change since the validators were received, Body is empty
+	NotModified bool
+	Body        []byte
+}
+
+// StatusError The server replied with a status that isn't 200 or 304
+type StatusError struct {
+	URL        string
+	StatusCode int
+	// RetryAfter When the server asked to be contacted again, zero if it didn't
+	RetryAfter time.Time
+}
+
+func (err *StatusError) Error() string {
+
+	if !err.RetryAfter.IsZero() {
+		return fmt.Sprintf("%s replied %d, retry after %v", err.URL, err.StatusCode, err.RetryAfter.Format(time.Kitchen))
+	}
+
+	return fmt.Sprintf("%s replied %d", err.URL, err.StatusCode)
+}
+
+// hostState The politeness state of a host, requests to it wait for a slot and the minimum interval
+type hostState struct {
+	slots        chan struct{}
+	mutex        sync.Mutex
+	nextRequest  time.Time
+	blockedUntil time.Time
+}
+
+// Fetcher Makes conditional GET requests, shared by every module so the limits apply across them
+type Fetcher struct {
+	mutex   sync.Mutex
+	clients map[clientKey]*http.Client
+	hosts   map[string]*hostState
+
+	// Timeout How long a request can take, including reading the body, applied to each request so changing it takes effect right away
+	Timeout time.Duration
+
+	// MaxPerHost How many requests to a host can be in flight at once, at least 1. Read when a host is first fetched
+	MaxPerHost int
+	// MinInterval The least time between the start of two requests to a host
+	MinInterval time.Duration
+}
+
+func NewFetcher() *Fetcher {
+	return &Fetcher{
+		clients:     map[clientKey]*http.Client{},
+		hosts:       map[string]*hostState{},
+		Timeout:     defaultTimeout,
+		MaxPerHost:  defaultMaxPerHost,
+		MinInterval: defaultMinInterval,
+	}
+}
+
+// Fetch Gets the URL, sending the validators as conditional headers and updating them from the response
+func (fetcher *Fetcher) Fetch(ctx context.Context, rawURL string, validators *Validators, options Options) (*Response, error) {
+
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+
+	host := fetcher.hostState(parsedURL.Host)
+
+	select {
+	case host.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	defer func() { <-host.slots }()
+
+	err = fetcher.waitForTurn(ctx, host, rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Started after waiting for the host, so only the request itself counts
+	if fetcher.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, fetcher.Timeout)
+		defer cancel()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+
+	for name, values := range options.Header {
+		request.Header[name] = values
+	}
+
+	if validators != nil {
+
+		if validators.ETag != "" {
+			request.Header.Set("If-None-Match", validators.ETag)
+		}
+
+		if validators.LastModified != "" {
+			request.Header.Set("If-Modified-Since", validators.LastModified)
+		}
+	}
+
+	client, err := fetcher.client(options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+
+	defer response.Body.Close()
+
+	switch {
+
+	case response.StatusCode == http.StatusNotModified:
+		return &Response{NotModified: true}, nil
+
+	case response.StatusCode == http.StatusTooManyRequests || response.StatusCode == http.StatusServiceUnavailable:
+
+		statusErr := &StatusError{URL: rawURL, StatusCode: response.StatusCode, RetryAfter: parseRetryAfter(response.Header.Get("Retry-After"), time.Now())}
+
+		if !statusErr.RetryAfter.IsZero() {
+			host.mutex.Lock()
+			host.blockedUntil = statusErr.RetryAfter
+			host.mutex.Unlock()
+		}
+
+		return nil, statusErr
+
+	case response.StatusCode < 200 || response.StatusCode > 299:
+		return nil, &StatusError{URL: rawURL, StatusCode: response.StatusCode}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", rawURL, err)
+	}
+
+	if validators != nil {
+		validators.ETag = response.Header.Get("ETag")
+		validators.LastModified = response.Header.Get("Last-Modified")
+	}
+
+	return &Response{Body: body}, nil
+}
+
+// client Returns the client for the options, creating it the first time so connections are reused
+func (fetcher *Fetcher) client(options Options) (*http.Client, error) {
+
+	key := clientKey{disableHTTP2: options.DisableHTTP2, proxy: options.Proxy}
+
+	fetcher.mutex.Lock()
+	defer fetcher.mutex.Unlock()
+
+	if client, ok := fetcher.clients[key]; ok {
+		return client, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if options.Proxy != "" {
+
+		proxyURL, err := url.Parse(options.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy: %w", err)
+		}
+
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if options.DisableHTTP2 {
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(authority string, c *tls.Conn) http.RoundTripper{}
+	}
+
+	client := &http.Client{Transport: transport}
+	fetcher.clients[key] = client
+
+	return client, nil
+}
+
+func (fetcher *Fetcher) hostState(name string) *hostState {
+
+	fetcher.mutex.Lock()
+	defer fetcher.mutex.Unlock()
+
+	state, ok := fetcher.hosts[name]
+	if !ok {
+		// Without a slot every request would wait until its context is done
+		maxPerHost := fetcher.MaxPerHost
+		if maxPerHost < 1 {
+			maxPerHost = 1
+		}
+
+		state = &hostState{slots: make(chan struct{}, maxPerHost)}
+		fetcher.hosts[name] = state
+	}
+
+	return state
+}
+
+// waitForTurn Waits out the minimum interval, failing right away if the host asked to be left alone for longer
+func (fetcher *Fetcher) waitForTurn(ctx context.Context, host *hostState, rawURL string) error {
+
+	host.mutex.Lock()
+
+	now := time.Now()
+
+	if host.blockedUntil.After(now) {
+		host.mutex.Unlock()
+		return fmt.Errorf("not fetching %s until %v, as asked by the server", rawURL, host.blockedUntil.Format(time.Kitchen))
+	}
+
+	start := now
+	if host.nextRequest.After(now) {
+		start = host.nextRequest
+	}
+
+	// Reserved before waiting, so concurrent requests line up behind each other
+	host.nextRequest = start.Add(fetcher.MinInterval)
+
+	host.mutex.Unlock()
+
+	timer := time.NewTimer(start.Sub(now))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// parseRetryAfter Parses either form of the Retry-After header, seconds or an HTTP date, returning zero if it's missing or invalid
+func parseRetryAfter(value string, now time.Time) time.Time {
+
+	if value == "" {
+		return time.Time{}
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err == nil {
+		return now.Add(time.Duration(seconds) * time.Second)
+	}
+
+	date, err := http.ParseTime(value)
+	if err == nil {
+		return date
+	}
+
+	return time.Time{}
+}
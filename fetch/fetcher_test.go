@@ -0,0 +1,145 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestFetcher() *Fetcher {
+
+	fetcher := NewFetcher()
+	fetcher.MinInterval = 0
+
+	return fetcher
+}
+
+func TestFetcher_conditional(test *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+
+		if request.Header.Get("If-None-Match") == `"v1"` && request.Header.Get("If-Modified-Since") == "Mon, 02 Jan 2006 15:04:05 GMT" {
+			writer.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		writer.Header().Set("ETag", `"v1"`)
+		writer.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		_, _ = writer.Write([]byte("<rss></rss>"))
+	}))
+	defer server.Close()
+
+	fetcher := newTestFetcher()
+	validators := &Validators{}
+
+	response, err := fetcher.Fetch(context.Background(), server.URL, validators, Options{})
+	assert.NoError(test, err)
+	assert.False(test, response.NotModified)
+	assert.Equal(test, "<rss></rss>", string(response.Body))
+	assert.Equal(test, Validators{ETag: `"v1"`, LastModified: "Mon, 02 Jan 2006 15:04:05 GMT"}, *validators)
+
+	response, err = fetcher.Fetch(context.Background(), server.URL, validators, Options{})
+	assert.NoError(test, err)
+	assert.True(test, response.NotModified)
+	assert.Empty(test, response.Body)
+}
+
+func TestFetcher_retryAfter(test *testing.T) {
+
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		writer.Header().Set("Retry-After", "120")
+		writer.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	fetcher := newTestFetcher()
+
+	_, err := fetcher.Fetch(context.Background(), server.URL, nil, Options{})
+
+	var statusErr *StatusError
+	assert.True(test, errors.As(err, &statusErr))
+	assert.Equal(test, http.StatusTooManyRequests, statusErr.StatusCode)
+	assert.WithinDuration(test, time.Now().Add(time.Minute*2), statusErr.RetryAfter, time.Second*5)
+
+	// The host isn't contacted again until the time it asked for
+	_, err = fetcher.Fetch(context.Background(), server.URL+"/other", nil, Options{})
+	assert.Error(test, err)
+	assert.Equal(test, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestFetcher_minInterval(test *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	defer server.Close()
+
+	fetcher := newTestFetcher()
+	fetcher.MinInterval = time.Millisecond * 100
+
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		_, err := fetcher.Fetch(context.Background(), server.URL, nil, Options{})
+		assert.NoError(test, err)
+	}
+
+	assert.GreaterOrEqual(test, time.Since(start), time.Millisecond*200)
+}
+
+func TestFetcher_maxPerHost(test *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	defer server.Close()
+
+	fetcher := newTestFetcher()
+	fetcher.MaxPerHost = 0
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	// Treated as one at a time instead of never
+	_, err := fetcher.Fetch(ctx, server.URL, nil, Options{})
+	assert.NoError(test, err)
+}
+
+func TestFetcher_timeout(test *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-request.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	fetcher := newTestFetcher()
+
+	_, err := fetcher.Fetch(context.Background(), server.URL, nil, Options{})
+	assert.NoError(test, err)
+
+	// The client for these options already exists, the new timeout still applies
+	fetcher.Timeout = time.Millisecond * 50
+
+	start := time.Now()
+
+	_, err = fetcher.Fetch(context.Background(), server.URL, nil, Options{})
+	assert.ErrorIs(test, err, context.DeadlineExceeded)
+	assert.Less(test, time.Since(start), time.Millisecond*500)
+}
+
+func TestParseRetryAfter(test *testing.T) {
+
+	now := time.Date(2022, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(test, now.Add(time.Second*30), parseRetryAfter("30", now))
+	assert.Equal(test, time.Date(2022, time.October, 1, 13, 0, 0, 0, time.UTC), parseRetryAfter("Sat, 01 Oct 2022 13:00:00 GMT", now).UTC())
+	assert.True(test, parseRetryAfter("soon", now).IsZero())
+	assert.True(test, parseRetryAfter("", now).IsZero())
+}
@@ -0,0 +1,45 @@
+package format
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/mmcdole/gofeed"
+	"privateInfoBot/data"
+	"privateInfoBot/utils"
+)
+
+// DefaultFormatter Posts an embed per item with its title, link, description as markdown and first image, used for feeds without a type
+type DefaultFormatter struct {
+	perItem
+}
+
+func (DefaultFormatter) Format(feed data.RSSFeed, items []*gofeed.Item) (messages []discordgo.MessageSend, err error) {
+
+	for _, item := range items {
+
+		embed, err := itemEmbed(feed, item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to format items: %w", err)
+		}
+
+		content := item.Description
+		if content == "" {
+			content = item.Content
+		}
+
+		converted := utils.ConvertHTML(content)
+
+		// The feed's description takes priority, same as the other formatters
+		if feed.Description == nil {
+			embed.Description = converted.Markdown
+		}
+
+		if converted.ImageURL != "" {
+			embed.Image = &discordgo.MessageEmbedImage{URL: converted.ImageURL}
+		}
+
+		messages = append(messages, discordgo.MessageSend{Embed: embed})
+	}
+
+	return
+}
@@ -0,0 +1,51 @@
+package format
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/mmcdole/gofeed"
+	"privateInfoBot/data"
+	"strings"
+)
+
+// Digest One message listing the links of items that were missed, whatever the feed's type
+func Digest(feed data.RSSFeed, items []*gofeed.Item) (discordgo.MessageSend, error) {
+
+	embed := &discordgo.MessageEmbed{
+		URL:   feed.FeedURL,
+		Title: fmt.Sprintf("Catching up on %d missed updates", len(items)),
+	}
+
+	if feed.Title != nil {
+		embed.Author = &discordgo.MessageEmbedAuthor{Name: *feed.Title}
+	}
+
+	applyThumbnail(feed, embed)
+
+	err := applyColor(feed, embed)
+	if err != nil {
+		return discordgo.MessageSend{}, fmt.Errorf("failed to format digest: %w", err)
+	}
+
+	var builder strings.Builder
+
+	for i, item := range items {
+
+		line := "• " + strings.TrimSpace(item.Title)
+		if item.Link != "" {
+			line = fmt.Sprintf("• [%s](%s)", strings.TrimSpace(item.Title), item.Link)
+		}
+
+		// Discord's embed description limit, leaving room for the remainder line
+		if builder.Len()+len(line) > 4000 {
+			builder.WriteString(fmt.Sprintf("…and %d more", len(items)-i))
+			break
+		}
+
+		builder.WriteString(line + "\n")
+	}
+
+	embed.Description = builder.String()
+
+	return discordgo.MessageSend{Embed: embed}, nil
+}
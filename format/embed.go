@@ -0,0 +1,131 @@
+package format
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/mmcdole/gofeed"
+	"privateInfoBot/data"
+	"strings"
+	"time"
+)
+
+func applyTitle(feed data.RSSFeed, embed *discordgo.MessageEmbed) {
+	if feed.Title != nil {
+		embed.Title = *feed.Title
+	}
+}
+
+func applyColor(feed data.RSSFeed, embed *discordgo.MessageEmbed) error {
+
+	if feed.Color != nil {
+
+		color, err := data.ParseColor(*feed.Color)
+		if err != nil {
+			return fmt.Errorf("applyColor failed: %w", err)
+		}
+
+		embed.Color = color
+	}
+
+	return nil
+}
+
+// EmbedColor The color for embeds of scrape sources and watched pages, 0 without one.
+// Their colors are checked when the config is read, so one that doesn't parse is left out instead of failing the post
+func EmbedColor(color *string) int {
+
+	if color == nil {
+		return 0
+	}
+
+	value, _ := data.ParseColor(*color)
+
+	return value
+}
+
+// Timestamp Formats the time for an embed, Discord only accepts ISO 8601 timestamps
+func Timestamp(t time.Time) string {
+	return t.Format(time.RFC3339)
+}
+
+func applyAuthor(feed data.RSSFeed, item *gofeed.Item, embed *discordgo.MessageEmbed) {
+	if feed.Author != nil {
+
+		author := *feed.Author
+		if len(item.Authors) > 0 {
+			author = strings.ReplaceAll(author, "${entryAuthor}", item.Authors[0].Name)
+		}
+
+		embed.Author = &discordgo.MessageEmbedAuthor{Name: author}
+	}
+}
+
+func applyThumbnail(feed data.RSSFeed, embed *discordgo.MessageEmbed) {
+	if feed.ThumbnailURL != nil {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: *feed.ThumbnailURL}
+	}
+}
+
+func applyDescription(feed data.RSSFeed, embed *discordgo.MessageEmbed) {
+	if feed.Description != nil {
+		embed.Description = *feed.Description
+	}
+}
+
+// itemEmbed An embed linking to the item, styled by the feed
+func itemEmbed(feed data.RSSFeed, item *gofeed.Item) (*discordgo.MessageEmbed, error) {
+
+	embed := &discordgo.MessageEmbed{
+		URL:   item.Link,
+		Title: item.Title,
+	}
+
+	if item.PublishedParsed != nil {
+		embed.Timestamp = Timestamp(*item.PublishedParsed)
+	}
+
+	applyAuthor(feed, item, embed)
+	applyThumbnail(feed, embed)
+	applyDescription(feed, embed)
+
+	err := applyColor(feed, embed)
+	if err != nil {
+		return nil, err
+	}
+
+	return embed, nil
+}
+
+// feedEmbed An embed linking to the feed, styled by it, for formatters that post items together
+func feedEmbed(feed data.RSSFeed, url string) (*discordgo.MessageEmbed, error) {
+
+	embed := &discordgo.MessageEmbed{URL: url}
+
+	applyTitle(feed, embed)
+	applyDescription(feed, embed)
+	applyThumbnail(feed, embed)
+
+	err := applyColor(feed, embed)
+	if err != nil {
+		return nil, err
+	}
+
+	return embed, nil
+}
+
+// titleLines The trimmed item titles, each prefixed by a green circle
+func titleLines(items []*gofeed.Item) []string {
+
+	lines := make([]string, 0, len(items))
+
+	for _, item := range items {
+
+		title := strings.TrimFunc(item.Title, func(r rune) bool {
+			return r == ' ' || r == '\n'
+		})
+
+		lines = append(lines, ":green_circle: "+title)
+	}
+
+	return lines
+}
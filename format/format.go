@@ -0,0 +1,92 @@
+package format
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/mmcdole/gofeed"
+	"privateInfoBot/data"
+	"sort"
+	"sync"
+)
+
+// Formatter Turns a feed's new items into the messages posted for them
+type Formatter interface {
+	// Batch Splits the items into the groups that are formatted and posted together
+	Batch(items []*gofeed.Item) [][]*gofeed.Item
+	Format(feed data.RSSFeed, items []*gofeed.Item) ([]discordgo.MessageSend, error)
+}
+
+var (
+	registryMutex sync.RWMutex
+	registry      = map[data.RSSType]Formatter{
+		data.Reddit:           RedditFormatter{},
+		data.Github:           GithubFormatter{},
+		data.TitleAndLink:     TitleAndLinkFormatter{},
+		data.KernelOrgUpdates: KernelOrgFormatter{},
+		data.GithubReleases:   GithubReleasesFormatter{},
+	}
+)
+
+// Register Adds the formatter for the type, replacing the one it had
+func Register(rssType data.RSSType, formatter Formatter) {
+
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	registry[rssType] = formatter
+}
+
+// For Returns the formatter for the type, DefaultFormatter for feeds without a type or with an unknown one
+func For(rssType *data.RSSType) Formatter {
+
+	if rssType == nil {
+		return DefaultFormatter{}
+	}
+
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	formatter, ok := registry[*rssType]
+	if !ok {
+		return DefaultFormatter{}
+	}
+
+	return formatter
+}
+
+// Types Returns the registered types, sorted
+func Types() []data.RSSType {
+
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	types := make([]data.RSSType, 0, len(registry))
+	for rssType := range registry {
+		types = append(types, rssType)
+	}
+
+	sort.Slice(types, func(i, j int) bool {
+		return types[i] < types[j]
+	})
+
+	return types
+}
+
+// perItem Posts every item on its own
+type perItem struct{}
+
+func (perItem) Batch(items []*gofeed.Item) [][]*gofeed.Item {
+
+	var batches [][]*gofeed.Item
+	for _, item := range items {
+		batches = append(batches, []*gofeed.Item{item})
+	}
+
+	return batches
+}
+
+// together Posts all the items at once
+type together struct{}
+
+func (together) Batch(items []*gofeed.Item) [][]*gofeed.Item {
+	return [][]*gofeed.Item{items}
+}
@@ -0,0 +1,370 @@
+package format
+
+import (
+	"flag"
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/json-iterator/go"
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"privateInfoBot/data"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// update Rewrites the golden files with the current output, run with `go test ./format -update`
+var update = flag.Bool("update", false, "update the golden files")
+
+// goldenJson Keeps the golden files readable
+var goldenJson = jsoniter.Config{EscapeHTML: false, SortMapKeys: true}.Froze()
+
+// readFixtureItems Reads testdata/<name>.xml, a saved feed, or if there isn't one testdata/<name>.items.json
+func readFixtureItems(test *testing.T, name string) []*gofeed.Item {
+
+	feedFile, err := os.Open(filepath.Join("testdata", name+".xml"))
+	if err == nil {
+
+		defer feedFile.Close()
+
+		feed, err := gofeed.NewParser().Parse(feedFile)
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		return feed.Items
+	}
+
+	itemsJson, err := os.ReadFile(filepath.Join("testdata", name+".items.json"))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	var items []*gofeed.Item
+
+	err = jsoniter.Unmarshal(itemsJson, &items)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	return items
+}
+
+// assertGolden Compares the messages to testdata/<name>.golden.json
+func assertGolden(test *testing.T, name string, messages []discordgo.MessageSend) {
+
+	// Embed isn't serialized, the same as when the messages are queued
+	for i := range messages {
+		if messages[i].Embed != nil {
+			messages[i].Embeds = append(messages[i].Embeds, messages[i].Embed)
+			messages[i].Embed = nil
+		}
+	}
+
+	actual, err := goldenJson.MarshalIndent(messages, "", "  ")
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	goldenPath := filepath.Join("testdata", name+".golden.json")
+
+	if *update {
+		err = os.WriteFile(goldenPath, append(actual, '\n'), 0644)
+		if err != nil {
+			test.Fatal(err)
+		}
+	}
+
+	expected, err := os.ReadFile(goldenPath)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	assert.JSONEq(test, string(expected), string(actual))
+}
+
+func TestFormatters_golden(test *testing.T) {
+
+	color := "#E1AD01"
+	title := "Kernel updates found!"
+	description := "https://www.kernel.org/"
+	thumbnail := "https://www.kernel.org/theme/images/logos/tux.png"
+	author := "${entryAuthor} on r/longevity"
+
+	reddit := data.Reddit
+	github := data.Github
+	kernelOrg := data.KernelOrgUpdates
+	titleAndLink := data.TitleAndLink
+	githubReleases := data.GithubReleases
+
+	show := data.ShowSensitive
+	skip := data.SkipSensitive
+
+	tests := []struct {
+		testName string
+		// fixture The items' file, defaults to the test's name
+		fixture string
+		feed    data.RSSFeed
+	}{
+		{testName: "reddit", feed: data.RSSFeed{FeedURL: "https://www.reddit.com/r/longevity.rss", Type: &reddit, Color: &color, Author: &author}},
+		{testName: "reddit_nsfw_show", fixture: "reddit", feed: data.RSSFeed{FeedURL: "https://www.reddit.com/r/longevity.rss", Type: &reddit, NSFW: &show}},
+		{testName: "reddit_nsfw_skip", fixture: "reddit", feed: data.RSSFeed{FeedURL: "https://www.reddit.com/r/longevity.rss", Type: &reddit, NSFW: &skip}},
+		{testName: "github", feed: data.RSSFeed{FeedURL: "https://github.com/Frogging-Family/linux-tkg/commits/master.atom", Type: &github, Color: &color, Title: &title, ThumbnailURL: &thumbnail}},
+		{testName: "kernel_org", feed: data.RSSFeed{FeedURL: "https://www.kernel.org/feeds/kdist.xml", Type: &kernelOrg, Color: &color, Title: &title, Description: &description, ThumbnailURL: &thumbnail}},
+		{testName: "title_and_link", feed: data.RSSFeed{FeedURL: "https://openai.com/blog/rss/", Type: &titleAndLink}},
+		{testName: "github_releases", feed: data.RSSFeed{FeedURL: "https://github.com/Frogging-Family/linux-tkg/releases.atom", Type: &githubReleases, Color: &color}},
+		{testName: "default", feed: data.RSSFeed{FeedURL: "https://example.com/feed.xml"}},
+	}
+
+	for _, testData := range tests {
+		test.Run(testData.testName, func(test *testing.T) {
+
+			fixture := testData.fixture
+			if fixture == "" {
+				fixture = testData.testName
+			}
+
+			formatter := For(testData.feed.Type)
+			items := readFixtureItems(test, fixture)
+
+			var messages []discordgo.MessageSend
+
+			for _, batch := range formatter.Batch(items) {
+
+				batchMessages, err := formatter.Format(testData.feed, batch)
+				assert.NoError(test, err)
+
+				messages = append(messages, batchMessages...)
+			}
+
+			assertGolden(test, testData.testName, messages)
+		})
+	}
+}
+
+func TestDigest_golden(test *testing.T) {
+
+	title := "Example posts"
+	feed := data.RSSFeed{FeedURL: "https://example.com/feed.xml", Title: &title}
+
+	message, err := Digest(feed, readFixtureItems(test, "digest"))
+	assert.NoError(test, err)
+
+	assertGolden(test, "digest", []discordgo.MessageSend{message})
+}
+
+func TestApplyTemplates_golden(test *testing.T) {
+
+	title := "Example posts"
+	github := data.Github
+
+	templates := data.MessageTemplates{
+		Content:     "New on {{ .Feed.Title }}",
+		Description: "{{ .Item.Description | markdown | truncate 20 }}",
+		Footer:      `{{ len .Items }} items, first published {{ date "2006-01-02" .Item.PublishedParsed }}`,
+		Fields:      []data.FieldTemplate{{Name: "Link", Value: "{{ .Item.Link }}", Inline: true}},
+	}
+
+	parsed, err := templates.Parse()
+	assert.NoError(test, err)
+
+	tests := []struct {
+		testName string
+		feed     data.RSSFeed
+		items    string
+	}{
+		{testName: "templates_per_item", feed: data.RSSFeed{FeedURL: "https://example.com/feed.xml", Title: &title}, items: "digest"},
+		{testName: "templates_together", feed: data.RSSFeed{FeedURL: "https://github.com/Frogging-Family/linux-tkg/commits/master.atom", Title: &title, Type: &github}, items: "github"},
+	}
+
+	for _, testData := range tests {
+		test.Run(testData.testName, func(test *testing.T) {
+
+			items := readFixtureItems(test, testData.items)
+
+			messages, err := For(testData.feed.Type).Format(testData.feed, items)
+			assert.NoError(test, err)
+
+			assert.NoError(test, ApplyTemplates(parsed, testData.feed, items, messages))
+
+			assertGolden(test, testData.testName, messages)
+		})
+	}
+}
+
+func TestFor(test *testing.T) {
+
+	unknown := data.RSSType("Unknown")
+	github := data.Github
+
+	assert.Equal(test, DefaultFormatter{}, For(nil))
+	assert.Equal(test, DefaultFormatter{}, For(&unknown))
+	assert.Equal(test, GithubFormatter{}, For(&github))
+}
+
+func TestFormatters_badColor(test *testing.T) {
+
+	badColor := "#nope"
+
+	for _, rssType := range Types() {
+
+		feedType := rssType
+		feed := data.RSSFeed{FeedURL: "https://example.com/feed.xml", Color: &badColor, Type: &feedType}
+
+		// TitleAndLink doesn't use embeds, so it has no color to fail on
+		if rssType == data.TitleAndLink {
+			continue
+		}
+
+		_, err := For(&feedType).Format(feed, []*gofeed.Item{{Title: "First"}})
+		assert.Error(test, err, rssType)
+	}
+}
+
+func TestEmbedColor(test *testing.T) {
+
+	green := "#00ff00"
+	withoutHash := "ff0000"
+	badColor := "#nope"
+
+	assert.Equal(test, 0x00ff00, EmbedColor(&green))
+	assert.Equal(test, 0xff0000, EmbedColor(&withoutHash))
+	assert.Equal(test, 0, EmbedColor(nil))
+	assert.Equal(test, 0, EmbedColor(&badColor), "colors are checked when the config is read, so one that slips through is left out")
+}
+
+func TestLimit(test *testing.T) {
+
+	embed := &discordgo.MessageEmbed{Title: "Kernel updates found!", Description: strings.Repeat("a", maxDescriptionLength+10), Color: 1}
+	for i := 0; i < 30; i++ {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Name", Value: strings.Repeat("b", 2000)})
+	}
+
+	messages := Limit([]discordgo.MessageSend{{Content: strings.Repeat("c", 2100), Embed: embed}})
+
+	assert.Equal(test, maxContentLength, utf8.RuneCountInString(messages[0].Content))
+	assert.Equal(test, maxDescriptionLength, utf8.RuneCountInString(embed.Description))
+	assert.True(test, strings.HasSuffix(embed.Description, "…"))
+
+	fieldCount := 0
+
+	for _, message := range messages {
+
+		assert.Nil(test, message.Embed)
+
+		length := 0
+		for _, messageEmbed := range message.Embeds {
+
+			assert.LessOrEqual(test, len(messageEmbed.Fields), maxFields)
+			assert.Equal(test, 1, messageEmbed.Color, "continuation embeds should keep the color")
+
+			for _, field := range messageEmbed.Fields {
+				assert.Equal(test, maxFieldValueLength, utf8.RuneCountInString(field.Value))
+			}
+
+			fieldCount += len(messageEmbed.Fields)
+			length += embedLength(messageEmbed)
+		}
+
+		assert.LessOrEqual(test, length, maxEmbedsLength)
+	}
+
+	assert.Equal(test, 30, fieldCount, "no field should be lost")
+	assert.Greater(test, len(messages), 1, "fields that don't fit should go into follow-up messages")
+}
+
+func TestLimit_withoutFields(test *testing.T) {
+
+	embed := &discordgo.MessageEmbed{
+		Title:       strings.Repeat("t", maxTitleLength),
+		Description: strings.Repeat("d", maxDescriptionLength),
+		Footer:      &discordgo.MessageEmbedFooter{Text: strings.Repeat("f", maxFooterLength)},
+		Author:      &discordgo.MessageEmbedAuthor{Name: strings.Repeat("a", maxAuthorNameLength)},
+	}
+
+	// Every part is at its own limit, so only the total is over
+	messages := Limit([]discordgo.MessageSend{{Embed: embed}})
+
+	assert.Len(test, messages, 1)
+	assert.Len(test, messages[0].Embeds, 1)
+	assert.Equal(test, maxEmbedsLength, embedLength(embed))
+	assert.Equal(test, maxTitleLength, utf8.RuneCountInString(embed.Title), "the title is kept whole")
+	assert.Equal(test, maxFooterLength, utf8.RuneCountInString(embed.Footer.Text), "cutting the description is enough")
+	assert.True(test, strings.HasSuffix(embed.Description, "…"))
+}
+
+func TestListFields(test *testing.T) {
+
+	var lines []string
+	for i := 0; i < 1000; i++ {
+		lines = append(lines, fmt.Sprintf(":green_circle: Commit number %d with a descriptive message", i))
+	}
+
+	fields := ListFields("New commit messages", lines, "\n\n", "https://github.com/example/repo/commits/master")
+
+	assert.Equal(test, "New commit messages", fields[0].Name)
+	assert.Equal(test, continuedFieldName, fields[1].Name)
+
+	totalLength := 0
+	for _, field := range fields {
+		assert.LessOrEqual(test, utf8.RuneCountInString(field.Value), maxFieldValueLength)
+		totalLength += utf8.RuneCountInString(field.Value)
+	}
+
+	assert.LessOrEqual(test, totalLength, maxListLength)
+	assert.Regexp(test, `\[\+\d+ more\]\(https://github.com/example/repo/commits/master\)$`, fields[len(fields)-1].Value)
+
+	// Small lists stay in one field
+	fields = ListFields("New versions", lines[:2], "\n\n", "")
+	assert.Len(test, fields, 1)
+	assert.Equal(test, lines[0]+"\n\n"+lines[1], fields[0].Value)
+}
+
+func TestParseGithubFeedURL(test *testing.T) {
+
+	tests := []struct {
+		feedURL     string
+		expected    GithubRepo
+		listURL     string
+		expectError bool
+	}{
+		{feedURL: "https://github.com/Frogging-Family/linux-tkg/commits/master.atom", expected: GithubRepo{URL: "https://github.com/Frogging-Family/linux-tkg", Kind: GithubCommits, Branch: "master"}, listURL: "https://github.com/Frogging-Family/linux-tkg/commits/master"},
+		{feedURL: "https://github.com/owner/repo/commits/feature/new-parser.atom", expected: GithubRepo{URL: "https://github.com/owner/repo", Kind: GithubCommits, Branch: "feature/new-parser"}, listURL: "https://github.com/owner/repo/commits/feature/new-parser"},
+		{feedURL: "https://github.com/owner/repo/commits.atom", expected: GithubRepo{URL: "https://github.com/owner/repo", Kind: GithubCommits}, listURL: "https://github.com/owner/repo/commits"},
+		{feedURL: "https://github.com/owner/repo/releases.atom", expected: GithubRepo{URL: "https://github.com/owner/repo", Kind: GithubReleases}, listURL: "https://github.com/owner/repo/releases"},
+		{feedURL: "https://github.com/owner/repo/tags.atom", expected: GithubRepo{URL: "https://github.com/owner/repo", Kind: GithubTags}, listURL: "https://github.com/owner/repo/tags"},
+		{feedURL: "https://github.com/owner/repo", expectError: true},
+		{feedURL: "https://github.com/owner/repo/issues.atom", expectError: true},
+	}
+
+	for _, testData := range tests {
+		test.Run(testData.feedURL, func(test *testing.T) {
+
+			repo, err := ParseGithubFeedURL(testData.feedURL)
+			if testData.expectError {
+				assert.Error(test, err)
+				return
+			}
+
+			assert.NoError(test, err)
+			assert.Equal(test, testData.expected, repo)
+			assert.Equal(test, testData.listURL, repo.ListURL())
+		})
+	}
+}
+
+func TestCodeBlock(test *testing.T) {
+
+	assert.Equal(test, "```diff\n-old\n+new ''' fence\n```", CodeBlock("diff", []string{"-old", "+new ``` fence"}))
+
+	var lines []string
+	for i := 0; i < 500; i++ {
+		lines = append(lines, fmt.Sprintf("+line number %d of a long diff", i))
+	}
+
+	block := CodeBlock("diff", lines)
+	assert.LessOrEqual(test, utf8.RuneCountInString(block), maxDescriptionLength)
+	assert.Regexp(test, "```\n\\+\\d+ more lines$", block)
+}
@@ -0,0 +1,253 @@
+package format
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/mmcdole/gofeed"
+	"github.com/pkg/errors"
+	"net/url"
+	"privateInfoBot/data"
+	"strings"
+	"time"
+)
+
+const shortSHALength = 7
+
+// GithubFeedKind What a GitHub atom feed lists
+type GithubFeedKind string
+
+const (
+	GithubCommits  = GithubFeedKind("commits")
+	GithubReleases = GithubFeedKind("releases")
+	GithubTags     = GithubFeedKind("tags")
+)
+
+// GithubRepo A repository parsed from one of its atom feed URLs
+type GithubRepo struct {
+	// URL The repository's page, ex: https://github.com/owner/repo
+	URL  string
+	Kind GithubFeedKind
+	// Branch The branch of a commits feed, empty for the default branch
+	Branch string
+}
+
+// ParseGithubFeedURL Parses the commits feed of any branch (/commits/<branch>.atom or /commits.atom), /releases.atom and /tags.atom
+func ParseGithubFeedURL(feedURL string) (GithubRepo, error) {
+
+	parsedURL, err := url.Parse(feedURL)
+	if err != nil {
+		return GithubRepo{}, errors.Wrap(err, "ParseGithubFeedURL failed")
+	}
+
+	parts := strings.SplitN(strings.Trim(strings.TrimSuffix(parsedURL.Path, ".atom"), "/"), "/", 4)
+	if len(parts) < 3 || parts[0] == "" || parts[1] == "" {
+		return GithubRepo{}, errors.Errorf("not a GitHub feed URL: %s", feedURL)
+	}
+
+	repo := GithubRepo{URL: fmt.Sprintf("%s://%s/%s/%s", parsedURL.Scheme, parsedURL.Host, parts[0], parts[1])}
+
+	switch GithubFeedKind(parts[2]) {
+
+	case GithubCommits:
+		repo.Kind = GithubCommits
+		// Branches can have slashes in them
+		if len(parts) == 4 {
+			repo.Branch = parts[3]
+		}
+
+	case GithubReleases, GithubTags:
+		if len(parts) == 4 {
+			return GithubRepo{}, errors.Errorf("not a GitHub feed URL: %s", feedURL)
+		}
+		repo.Kind = GithubFeedKind(parts[2])
+
+	default:
+		return GithubRepo{}, errors.Errorf("not a GitHub feed URL: %s", feedURL)
+	}
+
+	return repo, nil
+}
+
+// ListURL The page listing what the feed does, ex: the branch's commits
+func (repo GithubRepo) ListURL() string {
+
+	if repo.Kind == GithubCommits && repo.Branch != "" {
+		return repo.URL + "/commits/" + repo.Branch
+	}
+
+	return repo.URL + "/" + string(repo.Kind)
+}
+
+// CompareURL The changes between the commits, from the parent of the oldest up to the newest
+func (repo GithubRepo) CompareURL(oldestSHA string, newestSHA string) string {
+	return fmt.Sprintf("%s/compare/%s^...%s", repo.URL, oldestSHA, newestSHA)
+}
+
+// GithubFormatter Posts new commits in one embed, each with its short SHA, author and time, and a compare link across them
+type GithubFormatter struct {
+	together
+}
+
+func (GithubFormatter) Format(feed data.RSSFeed, items []*gofeed.Item) ([]discordgo.MessageSend, error) {
+
+	repo, err := ParseGithubFeedURL(feed.FeedURL)
+	if err != nil {
+		// Not a GitHub URL, ex: a mirror, the feed is still posted without the links
+		repo = GithubRepo{URL: strings.TrimSuffix(feed.FeedURL, ".atom"), Kind: GithubCommits}
+	}
+
+	embed, err := feedEmbed(feed, repo.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format Github items: %w", err)
+	}
+
+	applyCommitAuthor(feed, items, embed)
+
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, commitLine(item))
+	}
+
+	embed.Fields = ListFields("New commit messages", lines, "\n\n", repo.ListURL())
+
+	oldest, newest := commitRange(items)
+	if repo.Kind == GithubCommits && oldest != newest {
+
+		oldestSHA, newestSHA := commitSHA(oldest), commitSHA(newest)
+		if oldestSHA != "" && newestSHA != "" {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+				Name:  "Compare",
+				Value: fmt.Sprintf("[`%s^...%s`](%s)", shortSHA(oldestSHA), shortSHA(newestSHA), repo.CompareURL(oldestSHA, newestSHA)),
+			})
+		}
+	}
+
+	return []discordgo.MessageSend{{Embed: embed}}, nil
+}
+
+// commitLine The commit's title linked by its short SHA, followed by its author and relative time
+func commitLine(item *gofeed.Item) string {
+
+	title := strings.TrimFunc(item.Title, func(r rune) bool {
+		return r == ' ' || r == '\n'
+	})
+
+	line := ":green_circle: "
+
+	sha := commitSHA(item)
+	switch {
+	case sha != "" && item.Link != "":
+		line += fmt.Sprintf("[`%s`](%s) %s", shortSHA(sha), item.Link, title)
+	case item.Link != "":
+		line += fmt.Sprintf("[%s](%s)", title, item.Link)
+	default:
+		line += title
+	}
+
+	var details []string
+
+	if len(item.Authors) > 0 && item.Authors[0].Name != "" {
+		details = append(details, item.Authors[0].Name)
+	}
+
+	// Discord shows the timestamp relative to the reader's time, ex: "3 hours ago"
+	if commitTime := itemTime(item); commitTime != nil {
+		details = append(details, fmt.Sprintf("<t:%d:R>", commitTime.Unix()))
+	}
+
+	if len(details) > 0 {
+		line += " — " + strings.Join(details, ", ")
+	}
+
+	return line
+}
+
+// applyCommitAuthor Shows the author and their avatar when all the commits are theirs, a feed's own author is kept
+func applyCommitAuthor(feed data.RSSFeed, items []*gofeed.Item, embed *discordgo.MessageEmbed) {
+
+	if feed.Author != nil || len(items) == 0 || len(items[0].Authors) == 0 {
+		return
+	}
+
+	name := items[0].Authors[0].Name
+	for _, item := range items[1:] {
+		if len(item.Authors) == 0 || item.Authors[0].Name != name {
+			return
+		}
+	}
+
+	embed.Author = &discordgo.MessageEmbedAuthor{Name: name, IconURL: avatarURL(items[0])}
+}
+
+// avatarURL GitHub puts the author's avatar in the entry's media:thumbnail
+func avatarURL(item *gofeed.Item) string {
+
+	for _, thumbnail := range item.Extensions["media"]["thumbnail"] {
+		if thumbnail.Attrs["url"] != "" {
+			return thumbnail.Attrs["url"]
+		}
+	}
+
+	return ""
+}
+
+// commitSHA The commit's SHA from its link, ex: .../commit/<sha>, or its id, ex: tag:github.com,2008:Grit::Commit/<sha>
+func commitSHA(item *gofeed.Item) string {
+
+	if index := strings.LastIndex(item.Link, "/commit/"); index != -1 {
+		return item.Link[index+len("/commit/"):]
+	}
+
+	if index := strings.LastIndex(item.GUID, "Commit/"); index != -1 {
+		return item.GUID[index+len("Commit/"):]
+	}
+
+	return ""
+}
+
+func shortSHA(sha string) string {
+
+	if len(sha) <= shortSHALength {
+		return sha
+	}
+
+	return sha[:shortSHALength]
+}
+
+// commitRange The oldest and newest of the commits, by time, falling back to the feed's newest first order
+func commitRange(items []*gofeed.Item) (oldest *gofeed.Item, newest *gofeed.Item) {
+
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	oldest, newest = items[len(items)-1], items[0]
+
+	for _, item := range items {
+
+		current := itemTime(item)
+		if current == nil {
+			continue
+		}
+
+		if oldestTime := itemTime(oldest); oldestTime != nil && current.Before(*oldestTime) {
+			oldest = item
+		}
+
+		if newestTime := itemTime(newest); newestTime != nil && current.After(*newestTime) {
+			newest = item
+		}
+	}
+
+	return oldest, newest
+}
+
+// itemTime When the item was last updated, GitHub's feeds only have the updated time
+func itemTime(item *gofeed.Item) *time.Time {
+
+	if item.UpdatedParsed != nil {
+		return item.UpdatedParsed
+	}
+
+	return item.PublishedParsed
+}
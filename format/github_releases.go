@@ -0,0 +1,105 @@
+package format
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/mmcdole/gofeed"
+	"privateInfoBot/data"
+	"privateInfoBot/utils"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxChangelogLength How much of a release's changelog is posted, the rest is linked to
+const maxChangelogLength = 1500
+
+// GithubReleasesFormatter Posts an embed per release or tag with its name, tag, whether it's a pre-release and its changelog
+type GithubReleasesFormatter struct {
+	perItem
+}
+
+func (GithubReleasesFormatter) Format(feed data.RSSFeed, items []*gofeed.Item) (messages []discordgo.MessageSend, err error) {
+
+	for _, item := range items {
+
+		embed, err := itemEmbed(feed, item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to format Github releases: %w", err)
+		}
+
+		tag := ReleaseTag(item)
+		version, hasVersion := data.ParseVersion(tag)
+		isPrerelease := hasVersion && version.IsPrerelease()
+
+		embed.Title = strings.TrimSpace(item.Title)
+		if embed.Title == "" {
+			embed.Title = tag
+		}
+
+		if isPrerelease {
+			embed.Title = "[Pre-release] " + embed.Title
+		}
+
+		// Releases only have the updated time
+		if releaseTime := itemTime(item); releaseTime != nil {
+			embed.Timestamp = Timestamp(*releaseTime)
+		}
+
+		if feed.Author == nil && len(item.Authors) > 0 {
+			embed.Author = &discordgo.MessageEmbedAuthor{Name: item.Authors[0].Name, IconURL: avatarURL(item)}
+		}
+
+		if feed.Description == nil {
+			embed.Description = changelog(utils.HTMLToMarkdown(item.Content), item.Link)
+		}
+
+		embed.Fields = []*discordgo.MessageEmbedField{{Name: "Tag", Value: "`" + tag + "`", Inline: true}}
+		if isPrerelease {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Pre-release", Value: "Yes", Inline: true})
+		}
+
+		messages = append(messages, discordgo.MessageSend{Embed: embed})
+	}
+
+	return
+}
+
+// ReleaseTag The release's tag, ex: .../releases/tag/<tag>, falling back to its title for feeds that aren't GitHub's
+func ReleaseTag(item *gofeed.Item) string {
+
+	if index := strings.LastIndex(item.Link, "/releases/tag/"); index != -1 {
+		return item.Link[index+len("/releases/tag/"):]
+	}
+
+	return strings.TrimSpace(item.Title)
+}
+
+// changelog The markdown cut at the last full line within maxChangelogLength, linking to the full changelog if it was cut
+func changelog(markdown string, link string) string {
+
+	if utf8.RuneCountInString(markdown) <= maxChangelogLength {
+		return markdown
+	}
+
+	cut := []rune(markdown)[:maxChangelogLength]
+
+	trimmed := string(cut)
+	if index := strings.LastIndex(trimmed, "\n"); index > 0 {
+		trimmed = trimmed[:index]
+	}
+
+	trimmed = strings.TrimSpace(trimmed)
+
+	// A code block that was cut has to be closed, or the rest of the embed is code
+	if strings.Count(trimmed, "```")%2 == 1 {
+		trimmed += "\n```"
+	}
+
+	trimmed += "\n…"
+
+	if link != "" {
+		trimmed += fmt.Sprintf("\n\n[Full changelog](%s)", link)
+	}
+
+	return trimmed
+}
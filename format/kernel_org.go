@@ -0,0 +1,152 @@
+package format
+
+import (
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/bwmarrin/discordgo"
+	"github.com/mmcdole/gofeed"
+	"github.com/pkg/errors"
+	"privateInfoBot/data"
+	"strings"
+	"time"
+)
+
+// kernelMonikerNames The field names of the moniker groups
+var kernelMonikerNames = map[data.KernelMoniker]string{
+	data.Mainline:  "Mainline",
+	data.Stable:    "Stable",
+	data.Longterm:  "Longterm",
+	data.LinuxNext: "linux-next",
+}
+
+// KernelRelease A release from kernel.org's kdist.xml
+type KernelRelease struct {
+	// Version Ex: "6.0.2", "6.1-rc3" or "next-20221014"
+	Version  string
+	Moniker  data.KernelMoniker
+	Released *time.Time
+	// TarballURL, PatchURL and ChangelogURL are empty when the release doesn't have them, ex: linux-next has none
+	TarballURL   string
+	PatchURL     string
+	ChangelogURL string
+}
+
+// ParseKernelRelease Parses the item's title, ex: "6.0.2: stable", and the table of links in its description
+func ParseKernelRelease(item *gofeed.Item) (KernelRelease, error) {
+
+	version, moniker, found := strings.Cut(strings.TrimSpace(item.Title), ": ")
+	if !found || version == "" || moniker == "" {
+		return KernelRelease{}, errors.Errorf("not a kernel.org release: %s", item.Title)
+	}
+
+	release := KernelRelease{
+		Version:  version,
+		Moniker:  data.KernelMoniker(moniker),
+		Released: item.PublishedParsed,
+	}
+
+	document, err := goquery.NewDocumentFromReader(strings.NewReader(item.Description))
+	if err != nil {
+		return KernelRelease{}, errors.Wrap(err, "ParseKernelRelease failed")
+	}
+
+	document.Find("tr").Each(func(i int, row *goquery.Selection) {
+
+		cell := row.Find("td").First()
+		link, _ := cell.Find("a").First().Attr("href")
+
+		switch strings.TrimSuffix(strings.TrimSpace(row.Find("th").First().Text()), ":") {
+		case "Released":
+			if released, err := time.Parse("2006-01-02", strings.TrimSpace(cell.Text())); err == nil {
+				release.Released = &released
+			}
+		case "Source":
+			release.TarballURL = link
+		case "Patch":
+			release.PatchURL = link
+		case "ChangeLog":
+			release.ChangelogURL = link
+		}
+	})
+
+	return release, nil
+}
+
+// line The release's version, date and links
+func (release KernelRelease) line() string {
+
+	parts := []string{"**" + release.Version + "**"}
+
+	if release.Released != nil {
+		parts = append(parts, release.Released.Format("2006-01-02"))
+	}
+
+	links := []struct{ name, url string }{
+		{"tarball", release.TarballURL},
+		{"patch", release.PatchURL},
+		{"changelog", release.ChangelogURL},
+	}
+
+	for _, link := range links {
+		if link.url != "" {
+			parts = append(parts, fmt.Sprintf("[%s](%s)", link.name, link.url))
+		}
+	}
+
+	return ":green_circle: " + strings.Join(parts, " · ")
+}
+
+// KernelOrgFormatter Posts the new kernel versions in one embed, grouped by moniker
+type KernelOrgFormatter struct {
+	together
+}
+
+func (KernelOrgFormatter) Format(feed data.RSSFeed, items []*gofeed.Item) ([]discordgo.MessageSend, error) {
+
+	embed, err := feedEmbed(feed, feed.FeedURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format kernel.org items: %w", err)
+	}
+
+	groups := map[data.KernelMoniker][]string{}
+	monikers := append([]data.KernelMoniker{}, data.KernelMonikers...)
+
+	// Items that aren't releases are still posted, by their title
+	var otherLines []string
+
+	for _, item := range items {
+
+		release, err := ParseKernelRelease(item)
+		if err != nil {
+			otherLines = append(otherLines, titleLines([]*gofeed.Item{item})...)
+			continue
+		}
+
+		if _, ok := kernelMonikerNames[release.Moniker]; !ok && groups[release.Moniker] == nil {
+			monikers = append(monikers, release.Moniker)
+		}
+
+		groups[release.Moniker] = append(groups[release.Moniker], release.line())
+	}
+
+	for _, moniker := range monikers {
+
+		lines := groups[moniker]
+		if len(lines) == 0 {
+			continue
+		}
+
+		name, ok := kernelMonikerNames[moniker]
+		if !ok {
+			name = string(moniker)
+		}
+
+		embed.Fields = append(embed.Fields, ListFields(name, lines, "\n", "https://www.kernel.org/")...)
+	}
+
+	if len(otherLines) > 0 {
+		embed.Fields = append(embed.Fields, ListFields("Other", otherLines, "\n", "https://www.kernel.org/")...)
+	}
+
+	return []discordgo.MessageSend{{Embed: embed}}, nil
+}
@@ -0,0 +1,234 @@
+package format
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"privateInfoBot/utils"
+	"strings"
+	"unicode/utf8"
+)
+
+// Discord's message and embed limits, in characters
+const (
+	maxContentLength     = 2000
+	maxTitleLength       = 256
+	maxDescriptionLength = 4096
+	maxFields            = 25
+	maxFieldNameLength   = 256
+	maxFieldValueLength  = 1024
+	maxFooterLength      = 2048
+	maxAuthorNameLength  = 256
+	maxEmbeds            = 10
+	// maxEmbedsLength The limit of all the embeds in a message together
+	maxEmbedsLength = 6000
+	// maxListLength How much of a list is posted, about three embeds, the rest is linked to with "+N more"
+	maxListLength = 15000
+)
+
+// continuedFieldName Fields continuing a list have no name of their own, Discord requires one so it's a zero width space
+const continuedFieldName = "\u200b"
+
+// Limit Fits the messages into Discord's limits.
+// Parts over their limit are cut, embeds with too many fields are split into more embeds, and embeds that don't fit in one message go into follow-up messages.
+func Limit(messages []discordgo.MessageSend) []discordgo.MessageSend {
+
+	var result []discordgo.MessageSend
+
+	for _, message := range messages {
+
+		embeds := message.Embeds
+		if message.Embed != nil {
+			embeds = append(embeds, message.Embed)
+		}
+
+		var splitEmbeds []*discordgo.MessageEmbed
+		for _, embed := range embeds {
+			truncateEmbed(embed)
+			splitEmbeds = append(splitEmbeds, splitEmbed(embed)...)
+		}
+
+		message.Content = utils.Truncate(message.Content, maxContentLength)
+		message.Embed = nil
+		message.Embeds = nil
+
+		length := 0
+
+		for _, embed := range splitEmbeds {
+
+			embedLength := embedLength(embed)
+
+			if len(message.Embeds) > 0 && (len(message.Embeds) == maxEmbeds || length+embedLength > maxEmbedsLength) {
+				result = append(result, message)
+				message = discordgo.MessageSend{}
+				length = 0
+			}
+
+			message.Embeds = append(message.Embeds, embed)
+			length += embedLength
+		}
+
+		result = append(result, message)
+	}
+
+	return result
+}
+
+// splitEmbed Moves the fields that don't fit into continuation embeds with the same color
+func splitEmbed(embed *discordgo.MessageEmbed) []*discordgo.MessageEmbed {
+
+	if len(embed.Fields) <= maxFields && embedLength(embed) <= maxEmbedsLength {
+		return []*discordgo.MessageEmbed{embed}
+	}
+
+	fields := embed.Fields
+	embed.Fields = nil
+
+	embeds := []*discordgo.MessageEmbed{embed}
+	current := embed
+	length := embedLength(embed)
+
+	for _, field := range fields {
+
+		fieldLength := utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+
+		if len(current.Fields) > 0 && (len(current.Fields) == maxFields || length+fieldLength > maxEmbedsLength) {
+			current = &discordgo.MessageEmbed{Color: embed.Color}
+			embeds = append(embeds, current)
+			length = 0
+		}
+
+		current.Fields = append(current.Fields, field)
+		length += fieldLength
+	}
+
+	return embeds
+}
+
+// truncateEmbed Cuts every part of the embed that is over its own limit, then the description if the parts are over the total limit together
+func truncateEmbed(embed *discordgo.MessageEmbed) {
+
+	embed.Title = utils.Truncate(embed.Title, maxTitleLength)
+	embed.Description = utils.Truncate(embed.Description, maxDescriptionLength)
+
+	for _, field := range embed.Fields {
+		field.Name = utils.Truncate(field.Name, maxFieldNameLength)
+		field.Value = utils.Truncate(field.Value, maxFieldValueLength)
+	}
+
+	if embed.Footer != nil {
+		embed.Footer.Text = utils.Truncate(embed.Footer.Text, maxFooterLength)
+	}
+
+	if embed.Author != nil {
+		embed.Author.Name = utils.Truncate(embed.Author.Name, maxAuthorNameLength)
+	}
+
+	// Every part can fit on its own and still be over together, splitEmbed only moves fields out.
+	// The other parts add up to less than the total limit, so cutting the description is always enough
+	over := embedLength(&discordgo.MessageEmbed{Title: embed.Title, Description: embed.Description, Footer: embed.Footer, Author: embed.Author}) - maxEmbedsLength
+	if over > 0 {
+		embed.Description = utils.Truncate(embed.Description, utf8.RuneCountInString(embed.Description)-over)
+	}
+}
+
+// embedLength The characters that count towards the limit of all the embeds in a message
+func embedLength(embed *discordgo.MessageEmbed) int {
+
+	length := utf8.RuneCountInString(embed.Title) + utf8.RuneCountInString(embed.Description)
+
+	for _, field := range embed.Fields {
+		length += utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+	}
+
+	if embed.Footer != nil {
+		length += utf8.RuneCountInString(embed.Footer.Text)
+	}
+
+	if embed.Author != nil {
+		length += utf8.RuneCountInString(embed.Author.Name)
+	}
+
+	return length
+}
+
+// ListFields Puts the lines into as many fields as needed, the first one named name.
+// Lines past maxListLength are left out, the last field then ends with a "+N more" link to moreURL.
+func ListFields(name string, lines []string, separator string, moreURL string) []*discordgo.MessageEmbedField {
+
+	shown := make([]string, 0, len(lines))
+	totalLength := 0
+
+	for i, line := range lines {
+
+		line = utils.Truncate(line, maxFieldValueLength)
+		totalLength += utf8.RuneCountInString(line) + utf8.RuneCountInString(separator)
+
+		// Room is left for the "+N more" line
+		if totalLength > maxListLength-100 {
+
+			more := fmt.Sprintf("+%d more", len(lines)-i)
+			if moreURL != "" {
+				more = fmt.Sprintf("[%s](%s)", more, moreURL)
+			}
+
+			shown = append(shown, more)
+			break
+		}
+
+		shown = append(shown, line)
+	}
+
+	var fields []*discordgo.MessageEmbedField
+	current := &discordgo.MessageEmbedField{Name: name}
+
+	for _, line := range shown {
+
+		if current.Value != "" && utf8.RuneCountInString(current.Value)+utf8.RuneCountInString(separator+line) > maxFieldValueLength {
+			fields = append(fields, current)
+			current = &discordgo.MessageEmbedField{Name: continuedFieldName}
+		}
+
+		if current.Value != "" {
+			current.Value += separator
+		}
+
+		current.Value += line
+	}
+
+	return append(fields, current)
+}
+
+// CodeBlock Puts the lines in a code block that fits in an embed's description, the lines that don't fit are counted after it
+func CodeBlock(language string, lines []string) string {
+
+	var builder strings.Builder
+	builder.WriteString("```" + language + "\n")
+
+	// Room is left for the closing fence and the "+N more lines" line
+	room := maxDescriptionLength - utf8.RuneCountInString(builder.String()) - 100
+	length := 0
+	shown := 0
+
+	for _, line := range lines {
+
+		// A fence inside the block would end it early
+		line = strings.ReplaceAll(utils.Truncate(line, maxFieldValueLength), "```", "'''")
+
+		lineLength := utf8.RuneCountInString(line) + 1
+		if length+lineLength > room {
+			break
+		}
+
+		builder.WriteString(line + "\n")
+		length += lineLength
+		shown++
+	}
+
+	builder.WriteString("```")
+
+	if shown < len(lines) {
+		builder.WriteString(fmt.Sprintf("\n+%d more lines", len(lines)-shown))
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,218 @@
+package format
+
+import (
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/bwmarrin/discordgo"
+	"github.com/mmcdole/gofeed"
+	"net/url"
+	"privateInfoBot/data"
+	"privateInfoBot/utils"
+	"regexp"
+	"strings"
+)
+
+// maxSelfTextLength How much of a text post is posted
+const maxSelfTextLength = 1000
+
+var (
+	subredditPattern = regexp.MustCompile(`/r/([^/]+)/`)
+	nsfwPattern      = regexp.MustCompile(`(?i)\bnsfw\b`)
+	spoilerPattern   = regexp.MustCompile(`(?i)\bspoilers?\b`)
+	imageExtensions  = []string{".jpg", ".jpeg", ".png", ".gif", ".webp"}
+)
+
+// redditPost What's in a Reddit feed entry, its content is a table with the thumbnail, author and links
+type redditPost struct {
+	Subreddit  string
+	Flair      string
+	AuthorName string
+	AuthorURL  string
+	// LinkURL What the post links to, the post itself for text posts
+	LinkURL string
+	// ImageURL The linked image if the post is one, otherwise its thumbnail
+	ImageURL  string
+	SelfText  string
+	IsGallery bool
+	IsNSFW    bool
+	IsSpoiler bool
+}
+
+func parseRedditPost(item *gofeed.Item) (redditPost, error) {
+
+	document, err := goquery.NewDocumentFromReader(strings.NewReader(item.Content))
+	if err != nil {
+		return redditPost{}, err
+	}
+
+	var post redditPost
+
+	document.Find("a").Each(func(i int, link *goquery.Selection) {
+
+		href, _ := link.Attr("href")
+		text := strings.TrimSpace(link.Text())
+
+		switch {
+		case text == "[link]":
+			post.LinkURL = href
+		case strings.Contains(href, "/user/") && post.AuthorURL == "":
+			post.AuthorURL = href
+			post.AuthorName = text
+		}
+	})
+
+	if post.AuthorName == "" && len(item.Authors) > 0 {
+		post.AuthorName = item.Authors[0].Name
+	}
+
+	post.ImageURL, _ = document.Find("td img").First().Attr("src")
+	if post.ImageURL == "" {
+		post.ImageURL = avatarURL(item)
+	}
+
+	if isImageURL(post.LinkURL) {
+		post.ImageURL = post.LinkURL
+	}
+
+	post.IsGallery = strings.Contains(post.LinkURL, "/gallery/")
+
+	if selfText, err := document.Find("div.md").First().Html(); err == nil && selfText != "" {
+		post.SelfText = utils.Truncate(utils.HTMLToMarkdown(selfText), maxSelfTextLength)
+	}
+
+	if len(item.Categories) > 0 {
+		post.Subreddit = item.Categories[0]
+	} else if match := subredditPattern.FindStringSubmatch(item.Link); match != nil {
+		post.Subreddit = match[1]
+	}
+
+	post.Flair = strings.TrimSpace(document.Find(".flair, .linkflairlabel").First().Text())
+
+	markers := append([]string{item.Title, post.Flair}, item.Categories...)
+	for _, marker := range markers {
+		post.IsNSFW = post.IsNSFW || nsfwPattern.MatchString(marker)
+		post.IsSpoiler = post.IsSpoiler || spoilerPattern.MatchString(marker)
+	}
+
+	return post, nil
+}
+
+func isImageURL(link string) bool {
+
+	parsedURL, err := url.Parse(link)
+	if err != nil || link == "" {
+		return false
+	}
+
+	if parsedURL.Host == "i.redd.it" || parsedURL.Host == "i.imgur.com" {
+		return true
+	}
+
+	for _, extension := range imageExtensions {
+		if strings.HasSuffix(strings.ToLower(parsedURL.Path), extension) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RedditFormatter Posts an embed per post with what it links to, its image, subreddit and flair.
+// NSFW and spoiler posts are shown, hidden behind spoiler tags or skipped based on the feed's settings
+type RedditFormatter struct {
+	perItem
+}
+
+func (RedditFormatter) Format(feed data.RSSFeed, items []*gofeed.Item) (messages []discordgo.MessageSend, err error) {
+
+	for _, item := range items {
+
+		embed, err := itemEmbed(feed, item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to format Reddit items: %w", err)
+		}
+
+		post, err := parseRedditPost(item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to format Reddit items: %w", err)
+		}
+
+		policy := data.ShowSensitive
+		if post.IsNSFW {
+			policy = feed.GetNSFW()
+		}
+		if post.IsSpoiler && policy == data.ShowSensitive {
+			policy = feed.GetSpoilers()
+		}
+
+		if policy == data.SkipSensitive {
+			continue
+		}
+
+		// Posts without an author in the feed still have one in their content
+		if embed.Author != nil {
+			embed.Author.Name = strings.ReplaceAll(embed.Author.Name, "${entryAuthor}", post.AuthorName)
+		} else if post.AuthorName != "" {
+			embed.Author = &discordgo.MessageEmbedAuthor{Name: post.AuthorName, URL: post.AuthorURL}
+		}
+
+		if feed.Description == nil {
+
+			var description []string
+			if post.LinkURL != "" && post.LinkURL != item.Link {
+				description = append(description, post.LinkURL)
+			}
+			if post.SelfText != "" {
+				description = append(description, post.SelfText)
+			}
+
+			embed.Description = strings.Join(description, "\n\n")
+		}
+
+		if post.ImageURL != "" {
+			embed.Image = &discordgo.MessageEmbedImage{URL: post.ImageURL}
+		}
+
+		if post.Subreddit != "" {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Subreddit", Value: "r/" + post.Subreddit, Inline: true})
+		}
+
+		if post.Flair != "" {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Flair", Value: post.Flair, Inline: true})
+		}
+
+		if post.IsGallery {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Gallery", Value: fmt.Sprintf("[View all images](%s)", post.LinkURL), Inline: true})
+		}
+
+		if policy == data.SpoilerSensitive {
+			hideRedditPost(embed, post)
+		}
+
+		messages = append(messages, discordgo.MessageSend{Embed: embed})
+	}
+
+	return
+}
+
+// hideRedditPost Puts the title and description behind spoiler tags, embed titles and images can't be hidden so they're replaced and left out
+func hideRedditPost(embed *discordgo.MessageEmbed, post redditPost) {
+
+	label := "Spoiler"
+	if post.IsNSFW {
+		label = "NSFW"
+	}
+
+	hidden := "||" + embed.Title + "||"
+	if embed.Description != "" {
+		hidden += "\n\n||" + embed.Description + "||"
+	}
+
+	embed.Title = label + " post"
+	if post.Subreddit != "" {
+		embed.Title = fmt.Sprintf("%s post in r/%s", label, post.Subreddit)
+	}
+
+	embed.Description = hidden
+	embed.Image = nil
+}
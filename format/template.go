@@ -0,0 +1,101 @@
+package format
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/mmcdole/gofeed"
+	"privateInfoBot/data"
+)
+
+// ApplyTemplates Replaces the parts of the formatted messages the feed has templates for.
+// When there's a message per item each message uses its own item, otherwise every message gets all the items.
+func ApplyTemplates(templates *data.ParsedTemplates, feed data.RSSFeed, items []*gofeed.Item, messages []discordgo.MessageSend) error {
+
+	if templates == nil || len(items) == 0 {
+		return nil
+	}
+
+	for i := range messages {
+
+		templateData := data.TemplateData{Feed: feed, Item: items[0], Items: items}
+		if len(messages) == len(items) {
+			templateData.Item = items[i]
+			templateData.Items = items[i : i+1]
+		}
+
+		err := applyTemplates(templates, templateData, &messages[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func applyTemplates(templates *data.ParsedTemplates, templateData data.TemplateData, message *discordgo.MessageSend) error {
+
+	var err error
+
+	if templates.Content != nil {
+		message.Content, err = data.ExecuteTemplate(templates.Content, templateData)
+		if err != nil {
+			return err
+		}
+	}
+
+	hasEmbedTemplates := templates.Title != nil || templates.Description != nil || templates.Footer != nil || len(templates.Fields) > 0
+	if !hasEmbedTemplates {
+		return nil
+	}
+
+	embed := message.Embed
+	if embed == nil {
+		embed = &discordgo.MessageEmbed{URL: templateData.Item.Link}
+		message.Embed = embed
+	}
+
+	if templates.Title != nil {
+		embed.Title, err = data.ExecuteTemplate(templates.Title, templateData)
+		if err != nil {
+			return err
+		}
+	}
+
+	if templates.Description != nil {
+		embed.Description, err = data.ExecuteTemplate(templates.Description, templateData)
+		if err != nil {
+			return err
+		}
+	}
+
+	if templates.Footer != nil {
+
+		footer, err := data.ExecuteTemplate(templates.Footer, templateData)
+		if err != nil {
+			return err
+		}
+
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: footer}
+	}
+
+	if len(templates.Fields) > 0 {
+
+		embed.Fields = nil
+
+		for _, field := range templates.Fields {
+
+			name, err := data.ExecuteTemplate(field.Name, templateData)
+			if err != nil {
+				return err
+			}
+
+			value, err := data.ExecuteTemplate(field.Value, templateData)
+			if err != nil {
+				return err
+			}
+
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: name, Value: value, Inline: field.Inline})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package format
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/mmcdole/gofeed"
+	"privateInfoBot/data"
+)
+
+// TitleAndLinkFormatter Posts the bold title and the link, letting Discord unfurl it
+type TitleAndLinkFormatter struct {
+	perItem
+}
+
+func (TitleAndLinkFormatter) Format(feed data.RSSFeed, items []*gofeed.Item) (messages []discordgo.MessageSend, err error) {
+
+	for _, item := range items {
+		messages = append(messages,
+			discordgo.MessageSend{
+				Content: fmt.Sprintf("**%v**\n%v", item.Title, item.Link),
+			},
+		)
+	}
+
+	return
+}
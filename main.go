@@ -1,44 +1,53 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"github.com/bwmarrin/discordgo"
-	jsoniter "github.com/json-iterator/go"
 	"io/ioutil"
 	"log"
 	"os"
 	"os/signal"
+	"path"
+	"privateInfoBot/command"
 	"privateInfoBot/data"
+	"privateInfoBot/delivery"
+	"privateInfoBot/fetch"
 	"privateInfoBot/module"
+	"privateInfoBot/store"
 	"syscall"
 	"time"
 )
 
-// TODO: Mess with these: https://discord.com/developers/docs/interactions/message-components
-func main() {
+const (
+	rssFeedsFilePath      = "rssFeeds.json"
+	scrapeSourcesFilePath = "scrapeSources.json"
+	channelsFilePath      = "channels.json"
+	stateDirectory        = "Modules"
+	stateDatabasePath     = "Modules/state.db"
+	shutdownTimeout       = time.Second * 30
+)
 
-	rssFeedsJson, err := os.ReadFile("rssFeeds.json")
-	if err != nil {
-		log.Fatal(fmt.Errorf("failed to read rssFeeds: %w", err))
-	}
+var storeBackend = flag.String("store", "json", "where the modules save their state: json files or a bolt database, which imports the json files")
 
-	rssFeeds := new([]data.RSSFeed)
+func main() {
+
+	flag.Parse()
 
-	err = jsoniter.Unmarshal(rssFeedsJson, rssFeeds)
+	feedsConfig, err := data.ReadRSSFeeds(rssFeedsFilePath)
 	if err != nil {
-		log.Fatal(fmt.Errorf("failed to read rssFeeds: %w", err))
+		log.Fatal(err)
 	}
 
-	channelsJson, err := os.ReadFile("channels.json")
+	scrapeConfig, err := data.ReadScrapeSources(scrapeSourcesFilePath)
 	if err != nil {
-		log.Fatal(fmt.Errorf("failed to read channels: %w", err))
+		log.Fatal(err)
 	}
 
-	channels := new(map[string]uint64)
-
-	err = jsoniter.Unmarshal(channelsJson, channels)
+	channels, err := data.ReadChannels(channelsFilePath)
 	if err != nil {
-		log.Fatal(fmt.Errorf("failed to read channels: %w", err))
+		log.Fatal(err)
 	}
 
 	token, err := ioutil.ReadFile("token.txt")
@@ -46,34 +55,137 @@ func main() {
 		log.Fatal(fmt.Errorf("failed to read token: %w", err))
 	}
 
+	stateStore, err := openStore(*storeBackend)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	discord, err := discordgo.New("Bot " + string(token))
 	discord.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildMessages
+
+	commands := command.NewRegistry()
+
 	discord.AddHandler(onReady)
+	discord.AddHandler(commands.HandleInteraction)
 
 	err = discord.Open()
 	if err != nil {
 		log.Fatal(fmt.Errorf("failed to start discord bot: %w", err))
 	}
 
-	var rssModules []module.RSSUpdateModule
-	for _, feed := range *rssFeeds {
-		rssModule := module.NewRSSUpdateModule(time.Minute*30, feed, *channels, discord)
-		rssModules = append(rssModules, *rssModule)
-		rssModule.Enable()
+	manager := module.NewManager(context.Background())
+
+	queue, err := delivery.NewQueue(path.Join(stateDirectory, "Delivery"), discord)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	module.NewLongevityIORoadmapUpdateModule(
-		time.Minute*30,
-		(*channels)["longevityNews"],
-		discord,
-	).Enable()
+	deliveryModule := module.NewDeliveryModule(queue)
+
+	fetcher := fetch.NewFetcher()
+
+	feedManagerModule := module.NewFeedManagerModule(rssFeedsFilePath, channelsFilePath, feedsConfig, channels, time.Minute*30, queue, fetcher, stateStore)
+
+	calculatorModule := module.NewCalculatorModule()
+
+	manager.Add(deliveryModule, feedManagerModule, calculatorModule)
+
+	for _, source := range scrapeConfig.Sources {
+		if !source.Paused {
+			manager.Add(module.NewScrapeUpdateModule(time.Minute*30, source, channels, queue, fetcher, stateStore))
+		}
+	}
+
+	for _, page := range scrapeConfig.Pages {
+		if !page.Paused {
+			manager.Add(module.NewPageChangeModule(time.Minute*30, page, channels, queue, fetcher, stateStore))
+		}
+	}
+	manager.EnableAll()
+
+	for _, provider := range []command.Provider{deliveryModule, feedManagerModule, calculatorModule} {
+		err = commands.AddProvider(provider)
+		if err != nil {
+			log.Fatal(fmt.Errorf("failed to add commands: %w", err))
+		}
+	}
+
+	err = commands.Register(discord)
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to register commands: %w", err))
+	}
 
 	time.Sleep(time.Second * 2)
 
-	// Wait here until CTRL-C or other term signal is received.
+	// Wait here until CTRL-C or other term signal is received, SIGHUP reloads the feeds and channels.
 	sc := make(chan os.Signal, 1)
-	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt, os.Kill)
-	<-sc
+	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, os.Interrupt, os.Kill)
+
+	for signalReceived := range sc {
+
+		if signalReceived != syscall.SIGHUP {
+			break
+		}
+
+		err = feedManagerModule.Reload()
+		if err != nil {
+			log.Printf("%v", err)
+		}
+	}
+
+	fmt.Println("Shutting down, waiting for modules to finish.")
+
+	err = manager.Shutdown(shutdownTimeout)
+	if err != nil {
+		log.Printf("%v", err)
+	}
+
+	err = discord.Close()
+	if err != nil {
+		log.Printf("%v", fmt.Errorf("failed to close discord session: %w", err))
+	}
+
+	err = stateStore.Close()
+	if err != nil {
+		log.Printf("%v", fmt.Errorf("failed to close the state store: %w", err))
+	}
+}
+
+// openStore Opens the store the modules save their state in, the bolt database imports the state saved as json files it doesn't have yet
+func openStore(backend string) (store.Store, error) {
+
+	jsonStore := store.NewJSONStore(stateDirectory)
+
+	switch backend {
+
+	case "json":
+		return jsonStore, nil
+
+	case "bolt":
+
+		boltStore, err := store.OpenBoltStore(stateDatabasePath)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, prefix := range module.StatePrefixes {
+
+			copied, err := store.Migrate(jsonStore, boltStore, prefix)
+			if err != nil {
+				_ = boltStore.Close()
+				return nil, err
+			}
+
+			if copied > 0 {
+				fmt.Printf("Imported %d %s states from json files\n", copied, prefix)
+			}
+		}
+
+		return boltStore, nil
+
+	default:
+		return nil, fmt.Errorf("unknown store: %s, expected json or bolt", backend)
+	}
 }
 
 // This function will be called (due to AddHandler above) when the bot receives
@@ -0,0 +1,118 @@
+package math
+
+import (
+	"math"
+)
+
+// Evaluate Tokenizes, converts and evaluates an infix expression
+func Evaluate(expression string) (float64, error) {
+
+	tokens, err := Tokenize(expression)
+	if err != nil {
+		return 0, err
+	}
+
+	rpn, err := ToRPN(tokens)
+	if err != nil {
+		return 0, err
+	}
+
+	return EvaluateRPN(rpn)
+}
+
+// EvaluateRPN Evaluates tokens in reverse polish notation, as returned by ToRPN
+func EvaluateRPN(rpn []Token) (float64, error) {
+
+	var stack []float64
+
+	pop := func(amount int) []float64 {
+		values := stack[len(stack)-amount:]
+		stack = stack[:len(stack)-amount]
+		return values
+	}
+
+	for _, token := range rpn {
+
+		switch token.Type {
+
+		case Number:
+			stack = append(stack, token.Value)
+
+		case Operator:
+
+			arity := 2
+			if operators[token.Text].unary {
+				arity = 1
+			}
+
+			if len(stack) < arity {
+				return 0, errorAt(token.Position, "missing operand for %q", token.Text)
+			}
+
+			args := pop(arity)
+
+			result, err := applyOperator(token, args)
+			if err != nil {
+				return 0, err
+			}
+
+			stack = append(stack, result)
+
+		case Identifier:
+
+			if len(stack) < token.Arity {
+				return 0, errorAt(token.Position, "missing arguments for %s", token.Text)
+			}
+
+			// Copy since the stack will be appended to
+			args := append([]float64(nil), pop(token.Arity)...)
+
+			result, err := functions[token.Text].apply(args)
+			if err != nil {
+				if mathErr, ok := err.(*Error); ok {
+					mathErr.Position = token.Position
+				}
+				return 0, err
+			}
+
+			stack = append(stack, result)
+
+		default:
+			return 0, errorAt(token.Position, "unexpected %q in rpn", token.Text)
+		}
+	}
+
+	if len(stack) != 1 {
+		return 0, errorAt(0, "invalid expression")
+	}
+
+	return stack[0], nil
+}
+
+func applyOperator(token Token, args []float64) (float64, error) {
+
+	switch token.Text {
+	case unaryMinus:
+		return -args[0], nil
+	case "+":
+		return args[0] + args[1], nil
+	case "-":
+		return args[0] - args[1], nil
+	case "*":
+		return args[0] * args[1], nil
+	case "/":
+		if args[1] == 0 {
+			return 0, errorAt(token.Position, "division by zero")
+		}
+		return args[0] / args[1], nil
+	case "%":
+		if args[1] == 0 {
+			return 0, errorAt(token.Position, "division by zero")
+		}
+		return math.Mod(args[0], args[1]), nil
+	case "^":
+		return math.Pow(args[0], args[1]), nil
+	}
+
+	return 0, errorAt(token.Position, "unknown operator %q", token.Text)
+}
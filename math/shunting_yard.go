@@ -1 +1,284 @@
 package math
+
+import (
+	"math"
+)
+
+// unaryMinus The operator text used for a negation, to keep it apart from subtraction
+const unaryMinus = "neg"
+
+type operator struct {
+	precedence     int
+	rightAssociate bool
+	unary          bool
+}
+
+var operators = map[string]operator{
+	"+":        {precedence: 1},
+	"-":        {precedence: 1},
+	"*":        {precedence: 2},
+	"/":        {precedence: 2},
+	"%":        {precedence: 2},
+	unaryMinus: {precedence: 3, rightAssociate: true, unary: true},
+	"^":        {precedence: 4, rightAssociate: true},
+}
+
+type function struct {
+	minArgs int
+	// maxArgs is -1 for functions that take any amount of arguments
+	maxArgs int
+	apply   func(args []float64) (float64, error)
+}
+
+var functions = map[string]function{
+	"sqrt": {minArgs: 1, maxArgs: 1, apply: func(args []float64) (float64, error) {
+		if args[0] < 0 {
+			return 0, errorAt(0, "sqrt of a negative number")
+		}
+		return math.Sqrt(args[0]), nil
+	}},
+	"log": {minArgs: 1, maxArgs: 2, apply: func(args []float64) (float64, error) {
+		if len(args) == 2 {
+			return math.Log(args[0]) / math.Log(args[1]), nil
+		}
+		return math.Log10(args[0]), nil
+	}},
+	"ln":    {minArgs: 1, maxArgs: 1, apply: singleArg(math.Log)},
+	"sin":   {minArgs: 1, maxArgs: 1, apply: singleArg(math.Sin)},
+	"cos":   {minArgs: 1, maxArgs: 1, apply: singleArg(math.Cos)},
+	"tan":   {minArgs: 1, maxArgs: 1, apply: singleArg(math.Tan)},
+	"abs":   {minArgs: 1, maxArgs: 1, apply: singleArg(math.Abs)},
+	"floor": {minArgs: 1, maxArgs: 1, apply: singleArg(math.Floor)},
+	"ceil":  {minArgs: 1, maxArgs: 1, apply: singleArg(math.Ceil)},
+	"min": {minArgs: 1, maxArgs: -1, apply: func(args []float64) (float64, error) {
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = math.Min(result, arg)
+		}
+		return result, nil
+	}},
+	"max": {minArgs: 1, maxArgs: -1, apply: func(args []float64) (float64, error) {
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = math.Max(result, arg)
+		}
+		return result, nil
+	}},
+}
+
+var constants = map[string]float64{
+	"pi":  math.Pi,
+	"e":   math.E,
+	"tau": 2 * math.Pi,
+	"phi": math.Phi,
+}
+
+func singleArg(apply func(float64) float64) func(args []float64) (float64, error) {
+	return func(args []float64) (float64, error) {
+		return apply(args[0]), nil
+	}
+}
+
+// ToRPN Converts infix tokens into reverse polish notation using the shunting-yard algorithm
+func ToRPN(tokens []Token) ([]Token, error) {
+
+	var output []Token
+	var stack []Token
+
+	// Argument counts of the function calls currently open, nil entries are plain parentheses
+	var argCounts []*int
+
+	expectOperand := true
+	endPosition := 0
+
+	// Functions have to be followed by their argument list
+	var pendingCall *Token
+
+	for _, token := range tokens {
+
+		endPosition = token.Position + len(token.Text)
+
+		if pendingCall != nil && token.Type != LeftParen {
+			return nil, errorAt(token.Position, "expected '(' after %s", pendingCall.Text)
+		}
+
+		pendingCall = nil
+
+		switch token.Type {
+
+		case Number:
+
+			if !expectOperand {
+				return nil, errorAt(token.Position, "unexpected number %q", token.Text)
+			}
+
+			output = append(output, token)
+			expectOperand = false
+
+		case Identifier:
+
+			if !expectOperand {
+				return nil, errorAt(token.Position, "unexpected identifier %q", token.Text)
+			}
+
+			if value, ok := constants[token.Text]; ok {
+				token.Type = Number
+				token.Value = value
+				output = append(output, token)
+				expectOperand = false
+				continue
+			}
+
+			if _, ok := functions[token.Text]; !ok {
+				return nil, errorAt(token.Position, "unknown identifier %q", token.Text)
+			}
+
+			call := token
+			stack = append(stack, token)
+			pendingCall = &call
+
+		case Operator:
+
+			if expectOperand {
+
+				switch token.Text {
+				case "+":
+					// Unary plus does nothing
+					continue
+				case "-":
+					token.Text = unaryMinus
+					stack = append(stack, token)
+					continue
+				default:
+					return nil, errorAt(token.Position, "missing operand before %q", token.Text)
+				}
+			}
+
+			current := operators[token.Text]
+
+			for len(stack) > 0 {
+
+				top := stack[len(stack)-1]
+				if top.Type != Operator {
+					break
+				}
+
+				topOperator := operators[top.Text]
+				if topOperator.precedence < current.precedence || (topOperator.precedence == current.precedence && current.rightAssociate) {
+					break
+				}
+
+				output = append(output, top)
+				stack = stack[:len(stack)-1]
+			}
+
+			stack = append(stack, token)
+			expectOperand = true
+
+		case LeftParen:
+
+			if !expectOperand {
+				return nil, errorAt(token.Position, "unexpected '('")
+			}
+
+			isCall := len(stack) > 0 && stack[len(stack)-1].Type == Identifier
+			if isCall {
+				count := 0
+				argCounts = append(argCounts, &count)
+			} else {
+				argCounts = append(argCounts, nil)
+			}
+
+			stack = append(stack, token)
+
+		case Comma:
+
+			if len(argCounts) == 0 || argCounts[len(argCounts)-1] == nil {
+				return nil, errorAt(token.Position, "unexpected ',' outside of a function call")
+			}
+
+			if expectOperand {
+				return nil, errorAt(token.Position, "missing argument before ','")
+			}
+
+			output, stack = popUntilLeftParen(output, stack)
+			*argCounts[len(argCounts)-1]++
+			expectOperand = true
+
+		case RightParen:
+
+			if len(argCounts) == 0 {
+				return nil, errorAt(token.Position, "unmatched ')'")
+			}
+
+			argCount := argCounts[len(argCounts)-1]
+			argCounts = argCounts[:len(argCounts)-1]
+
+			// Allow an empty call so the arity check can report it
+			isEmptyCall := argCount != nil && *argCount == 0 && expectOperand && stack[len(stack)-1].Type == LeftParen
+			if expectOperand && !isEmptyCall {
+				return nil, errorAt(token.Position, "missing operand before ')'")
+			}
+
+			output, stack = popUntilLeftParen(output, stack)
+
+			// Pop the left paren
+			stack = stack[:len(stack)-1]
+
+			if argCount != nil {
+
+				function := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+
+				function.Arity = *argCount
+				if !isEmptyCall {
+					function.Arity++
+				}
+
+				definition := functions[function.Text]
+				if function.Arity < definition.minArgs || (definition.maxArgs != -1 && function.Arity > definition.maxArgs) {
+					return nil, errorAt(function.Position, "wrong amount of arguments for %s: %d", function.Text, function.Arity)
+				}
+
+				output = append(output, function)
+			}
+
+			expectOperand = false
+		}
+	}
+
+	if pendingCall != nil {
+		return nil, errorAt(endPosition, "expected '(' after %s", pendingCall.Text)
+	}
+
+	if expectOperand {
+		if len(tokens) == 0 {
+			return nil, errorAt(0, "empty expression")
+		}
+		return nil, errorAt(endPosition, "missing operand at end of expression")
+	}
+
+	for len(stack) > 0 {
+
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if top.Type == LeftParen {
+			return nil, errorAt(top.Position, "unmatched '('")
+		}
+
+		output = append(output, top)
+	}
+
+	return output, nil
+}
+
+func popUntilLeftParen(output []Token, stack []Token) ([]Token, []Token) {
+
+	for len(stack) > 0 && stack[len(stack)-1].Type != LeftParen {
+		output = append(output, stack[len(stack)-1])
+		stack = stack[:len(stack)-1]
+	}
+
+	return output, stack
+}
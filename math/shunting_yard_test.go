@@ -0,0 +1,96 @@
+package math
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEvaluate(test *testing.T) {
+
+	tests := []struct {
+		testName   string
+		expression string
+		expected   float64
+	}{
+		{testName: "precedence", expression: "1 + 2 * 3", expected: 7},
+		{testName: "parentheses", expression: "(1 + 2) * 3", expected: 9},
+		{testName: "leftAssociative", expression: "10 - 4 - 3", expected: 3},
+		{testName: "division", expression: "8 / 4 / 2", expected: 1},
+		{testName: "rightAssociativePower", expression: "2 ^ 3 ^ 2", expected: 512},
+		{testName: "unaryMinus", expression: "-3 + 5", expected: 2},
+		{testName: "unaryMinusBindsLooserThanPower", expression: "-2 ^ 2", expected: -4},
+		{testName: "negativeExponent", expression: "2 ^ -1", expected: 0.5},
+		{testName: "doubleNegation", expression: "--4", expected: 4},
+		{testName: "modulo", expression: "10 % 4", expected: 2},
+		{testName: "scientific", expression: "1.5e3 + 2E-1", expected: 1500.2},
+		{testName: "sqrt", expression: "sqrt(16) + 1", expected: 5},
+		{testName: "log10", expression: "log(1000)", expected: 3},
+		{testName: "logBase", expression: "log(8, 2)", expected: 3},
+		{testName: "trig", expression: "sin(0) + cos(0)", expected: 1},
+		{testName: "minMax", expression: "max(1, min(7, 3), 2)", expected: 3},
+		{testName: "nestedCall", expression: "max(1, 2 * (3 + 1))", expected: 8},
+		{testName: "constants", expression: "2 * PI", expected: 2 * math.Pi},
+		{testName: "implicitConstant", expression: "e ^ 1", expected: math.E},
+	}
+
+	for _, testData := range tests {
+		test.Run(testData.testName, func(test *testing.T) {
+			result, err := Evaluate(testData.expression)
+			assert.NoError(test, err)
+			assert.InDelta(test, testData.expected, result, 1e-9)
+		})
+	}
+}
+
+func TestEvaluate_errors(test *testing.T) {
+
+	tests := []struct {
+		testName         string
+		expression       string
+		expectedPosition int
+		expectedMessage  string
+	}{
+		{testName: "empty", expression: "", expectedPosition: 0, expectedMessage: "empty expression"},
+		{testName: "unknownCharacter", expression: "1 + $", expectedPosition: 4, expectedMessage: "unexpected character"},
+		{testName: "unknownIdentifier", expression: "2 * foo", expectedPosition: 4, expectedMessage: "unknown identifier"},
+		{testName: "trailingOperator", expression: "1 +", expectedPosition: 3, expectedMessage: "missing operand"},
+		{testName: "missingOperand", expression: "* 2", expectedPosition: 0, expectedMessage: "missing operand"},
+		{testName: "adjacentNumbers", expression: "1 2", expectedPosition: 2, expectedMessage: "unexpected number"},
+		{testName: "unmatchedLeft", expression: "(1 + 2", expectedPosition: 0, expectedMessage: "unmatched '('"},
+		{testName: "unmatchedRight", expression: "1 + 2)", expectedPosition: 5, expectedMessage: "unmatched ')'"},
+		{testName: "functionWithoutCall", expression: "sqrt 4", expectedPosition: 5, expectedMessage: "expected '('"},
+		{testName: "wrongArity", expression: "sqrt(1, 2)", expectedPosition: 0, expectedMessage: "wrong amount of arguments"},
+		{testName: "emptyCall", expression: "max()", expectedPosition: 0, expectedMessage: "wrong amount of arguments"},
+		{testName: "commaOutsideCall", expression: "(1, 2)", expectedPosition: 2, expectedMessage: "outside of a function call"},
+		{testName: "divisionByZero", expression: "1 / (2 - 2)", expectedPosition: 2, expectedMessage: "division by zero"},
+		{testName: "negativeSqrt", expression: "1 + sqrt(-1)", expectedPosition: 4, expectedMessage: "sqrt of a negative number"},
+	}
+
+	for _, testData := range tests {
+		test.Run(testData.testName, func(test *testing.T) {
+
+			_, err := Evaluate(testData.expression)
+
+			mathErr, ok := err.(*Error)
+			if !assert.True(test, ok, "expected *Error, got %v", err) {
+				return
+			}
+
+			assert.Equal(test, testData.expectedPosition, mathErr.Position)
+			assert.Contains(test, mathErr.Message, testData.expectedMessage)
+		})
+	}
+}
+
+func TestError_Annotate(test *testing.T) {
+
+	_, err := Evaluate("1 + * 2")
+
+	annotated := err.(*Error).Annotate("1 + * 2")
+	lines := strings.Split(annotated, "\n")
+
+	assert.Equal(test, "1 + * 2", lines[0])
+	assert.Equal(test, "    ^ missing operand before \"*\"", lines[1])
+}
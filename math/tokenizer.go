@@ -0,0 +1,142 @@
+package math
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type TokenType int
+
+const (
+	Number TokenType = iota
+	Identifier
+	Operator
+	LeftParen
+	RightParen
+	Comma
+)
+
+type Token struct {
+	Type     TokenType
+	Text     string
+	Value    float64
+	Position int
+	// Arity is the amount of arguments a function token was called with, only set by ToRPN
+	Arity int
+}
+
+// Error An error with the position in the expression it happened at
+type Error struct {
+	Position int
+	Message  string
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("%s at position %d", err.Message, err.Position+1)
+}
+
+// Annotate Returns the expression with a caret under the position of the error
+func (err *Error) Annotate(expression string) string {
+
+	position := err.Position
+	if position > len(expression) {
+		position = len(expression)
+	}
+
+	return fmt.Sprintf("%s\n%s^ %s", expression, strings.Repeat(" ", position), err.Message)
+}
+
+func errorAt(position int, format string, args ...interface{}) *Error {
+	return &Error{Position: position, Message: fmt.Sprintf(format, args...)}
+}
+
+// Tokenize Splits an expression into numbers, identifiers, operators, parentheses and commas
+func Tokenize(expression string) ([]Token, error) {
+
+	var tokens []Token
+
+	for index := 0; index < len(expression); {
+
+		char := rune(expression[index])
+
+		switch {
+
+		case unicode.IsSpace(char):
+			index++
+
+		case isDigit(expression[index]) || char == '.':
+
+			start := index
+			for index < len(expression) && (isDigit(expression[index]) || expression[index] == '.') {
+				index++
+			}
+
+			// Scientific notation, ex: 1e10, 2.5E-3
+			if index < len(expression) && (expression[index] == 'e' || expression[index] == 'E') {
+
+				exponentEnd := index + 1
+				if exponentEnd < len(expression) && (expression[exponentEnd] == '+' || expression[exponentEnd] == '-') {
+					exponentEnd++
+				}
+
+				if exponentEnd < len(expression) && isDigit(expression[exponentEnd]) {
+					index = exponentEnd
+					for index < len(expression) && isDigit(expression[index]) {
+						index++
+					}
+				}
+			}
+
+			text := expression[start:index]
+
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, errorAt(start, "invalid number %q", text)
+			}
+
+			tokens = append(tokens, Token{Type: Number, Text: text, Value: value, Position: start})
+
+		case isLetter(expression[index]):
+
+			start := index
+			for index < len(expression) && (isLetter(expression[index]) || isDigit(expression[index])) {
+				index++
+			}
+
+			tokens = append(tokens, Token{Type: Identifier, Text: strings.ToLower(expression[start:index]), Position: start})
+
+		case strings.ContainsRune("+-*/%^", char):
+			tokens = append(tokens, Token{Type: Operator, Text: string(char), Position: index})
+			index++
+
+		case char == '(':
+			tokens = append(tokens, Token{Type: LeftParen, Text: "(", Position: index})
+			index++
+
+		case char == ')':
+			tokens = append(tokens, Token{Type: RightParen, Text: ")", Position: index})
+			index++
+
+		case char == ',':
+			tokens = append(tokens, Token{Type: Comma, Text: ",", Position: index})
+			index++
+
+		default:
+			unexpected, _ := utf8.DecodeRuneInString(expression[index:])
+			return nil, errorAt(index, "unexpected character %q", unexpected)
+		}
+	}
+
+	return tokens, nil
+}
+
+func isDigit(char byte) bool {
+	return char >= '0' && char <= '9'
+}
+
+func isLetter(char byte) bool {
+	return char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char == '_'
+}
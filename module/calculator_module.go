@@ -0,0 +1,83 @@
+package module
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"privateInfoBot/command"
+	"privateInfoBot/math"
+	"strconv"
+	"sync"
+)
+
+// CalculatorModule Provides the /calc command
+type CalculatorModule struct {
+	// mutex Guards isEnabled, the command handler reads it from discordgo's goroutines
+	mutex     sync.Mutex
+	isEnabled bool
+}
+
+func NewCalculatorModule() *CalculatorModule {
+	return &CalculatorModule{}
+}
+
+func (module *CalculatorModule) IsEnabled() bool {
+	module.mutex.Lock()
+	defer module.mutex.Unlock()
+	return module.isEnabled
+}
+
+func (module *CalculatorModule) Enable() {
+	module.mutex.Lock()
+	defer module.mutex.Unlock()
+	module.isEnabled = true
+}
+
+func (module *CalculatorModule) Disable() {
+	module.mutex.Lock()
+	defer module.mutex.Unlock()
+	module.isEnabled = false
+}
+
+func (module *CalculatorModule) Commands() []*command.Command {
+	return []*command.Command{
+		{
+			Definition: &discordgo.ApplicationCommand{
+				Name:        "calc",
+				Description: "Evaluates a math expression",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "expression",
+						Description: "The expression to evaluate, ex: 2 * (3 + sqrt(16))",
+						Required:    true,
+					},
+				},
+			},
+			Handler: module.onCalc,
+		},
+	}
+}
+
+func (module *CalculatorModule) onCalc(session *discordgo.Session, interaction *discordgo.InteractionCreate, options command.Options) error {
+
+	if !module.IsEnabled() {
+		return command.RespondEphemeral(session, interaction, "The calculator is disabled")
+	}
+
+	expression, err := options.RequireString("expression")
+	if err != nil {
+		return err
+	}
+
+	result, err := math.Evaluate(expression)
+	if err != nil {
+
+		if mathErr, ok := err.(*math.Error); ok {
+			return command.RespondEphemeral(session, interaction, fmt.Sprintf("```\n%s\n```", mathErr.Annotate(expression)))
+		}
+
+		return err
+	}
+
+	return command.Respond(session, interaction, fmt.Sprintf("`%s` = **%s**", expression, strconv.FormatFloat(result, 'g', 15, 64)))
+}
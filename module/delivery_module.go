@@ -0,0 +1,119 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"privateInfoBot/command"
+	"privateInfoBot/delivery"
+	"strings"
+)
+
+// DeliveryModule Runs the delivery queue the other modules post through, and provides the /delivery command
+type DeliveryModule struct {
+	supervisor supervisor
+	queue      *delivery.Queue
+}
+
+func NewDeliveryModule(queue *delivery.Queue) *DeliveryModule {
+	return &DeliveryModule{
+		supervisor: newSupervisor("DeliveryQueue"),
+		queue:      queue,
+	}
+}
+
+func (module *DeliveryModule) IsEnabled() bool {
+	return module.supervisor.isRunning()
+}
+
+func (module *DeliveryModule) Enable() {
+	module.supervisor.start(module.queue.Run)
+}
+
+// Disable Stops delivering, waiting for an in-flight send to finish, the queue stays saved
+func (module *DeliveryModule) Disable() {
+	module.supervisor.stop()
+}
+
+func (module *DeliveryModule) bind(parent context.Context) {
+	module.supervisor.bind(parent)
+}
+
+func (module *DeliveryModule) Commands() []*command.Command {
+	return []*command.Command{
+		{
+			Definition: &discordgo.ApplicationCommand{
+				Name:        "delivery",
+				Description: "Inspects the delivery queue",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "status",
+						Description: "Shows the queued deliveries and the latest dead letters",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "retry",
+						Description: "Queues every dead letter again",
+					},
+				},
+			},
+			Handler: module.onDelivery,
+		},
+	}
+}
+
+func (module *DeliveryModule) onDelivery(session *discordgo.Session, interaction *discordgo.InteractionCreate, options command.Options) error {
+
+	subcommand, _, ok := options.Subcommand()
+	if !ok {
+		return fmt.Errorf("missing subcommand")
+	}
+
+	switch subcommand {
+
+	case "status":
+		return command.RespondEphemeral(session, interaction, module.statusMessage())
+
+	case "retry":
+
+		if !command.HasPermission(interaction, discordgo.PermissionManageServer) {
+			return command.RespondEphemeral(session, interaction, "You need the Manage Server permission to retry deliveries")
+		}
+
+		count, err := module.queue.RetryDeadLetters()
+		if err != nil {
+			return err
+		}
+
+		return command.RespondEphemeral(session, interaction, fmt.Sprintf("Queued %d dead letters again", count))
+	}
+
+	return fmt.Errorf("unknown subcommand %q", subcommand)
+}
+
+func (module *DeliveryModule) statusMessage() string {
+
+	pending := module.queue.Pending()
+	deadLetters := module.queue.DeadLetters()
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Queued: %d\nDead letters: %d\n", len(pending), len(deadLetters)))
+
+	// Newest first
+	for i := len(deadLetters) - 1; i >= 0 && len(deadLetters)-i <= 10; i-- {
+
+		deadLetter := deadLetters[i]
+
+		line := fmt.Sprintf("`%s` <#%s> after %d attempts: %s\n", deadLetter.ID, deadLetter.ChannelID, deadLetter.Attempts, deadLetter.LastError)
+
+		// Discord's message limit
+		if builder.Len()+len(line) > 2000 {
+			break
+		}
+
+		builder.WriteString(line)
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,603 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"log"
+	"net/url"
+	"os"
+	"privateInfoBot/command"
+	"privateInfoBot/data"
+	"privateInfoBot/delivery"
+	"privateInfoBot/fetch"
+	"privateInfoBot/format"
+	"privateInfoBot/store"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// configWatchDelay How often the config files are checked for changes
+const configWatchDelay = time.Second * 10
+
+// FeedManagerModule Owns the RSSUpdateModules and provides the /feed command to change them at runtime
+type FeedManagerModule struct {
+	supervisor       supervisor
+	mutex            sync.Mutex
+	feedsFilePath    string
+	channelsFilePath string
+	checkDelay       time.Duration
+	feeds            []data.RSSFeed
+	profiles         map[string]data.TransportProfile
+	channels         map[string]uint64
+	rssModules       map[string]*RSSUpdateModule
+	configModTimes   map[string]time.Time
+	queue            *delivery.Queue
+	fetcher          *fetch.Fetcher
+	store            store.Store
+}
+
+func NewFeedManagerModule(
+	feedsFilePath string,
+	channelsFilePath string,
+	config data.FeedsConfig,
+	channels map[string]uint64,
+	checkDelay time.Duration,
+	queue *delivery.Queue,
+	fetcher *fetch.Fetcher,
+	stateStore store.Store,
+) *FeedManagerModule {
+	return &FeedManagerModule{
+		supervisor:       newSupervisor("FeedManager"),
+		feedsFilePath:    feedsFilePath,
+		channelsFilePath: channelsFilePath,
+		checkDelay:       checkDelay,
+		feeds:            config.Feeds,
+		profiles:         config.Profiles,
+		channels:         channels,
+		rssModules:       map[string]*RSSUpdateModule{},
+		configModTimes:   map[string]time.Time{},
+		queue:            queue,
+		fetcher:          fetcher,
+		store:            stateStore,
+	}
+}
+
+func (module *FeedManagerModule) IsEnabled() bool {
+	return module.supervisor.isRunning()
+}
+
+func (module *FeedManagerModule) Enable() {
+
+	module.mutex.Lock()
+	defer module.mutex.Unlock()
+
+	if module.supervisor.isRunning() {
+		return
+	}
+
+	for _, feed := range module.feeds {
+
+		rssModule, ok := module.rssModules[feed.FeedURL]
+		if !ok {
+			rssModule = module.newRSSModule(feed, module.config(), module.channels)
+			module.rssModules[feed.FeedURL] = rssModule
+		}
+
+		if !feed.Paused {
+			rssModule.Enable()
+		}
+	}
+
+	module.rememberConfigModTimes()
+	module.supervisor.start(module.watchTask)
+}
+
+// Disable Stops watching the config and disables every feed, waiting for them to finish
+func (module *FeedManagerModule) Disable() {
+
+	// Stopped without holding the lock, since a reload in progress needs it
+	module.supervisor.stop()
+
+	module.mutex.Lock()
+	defer module.mutex.Unlock()
+
+	var waitGroup sync.WaitGroup
+
+	for _, rssModule := range module.rssModules {
+		waitGroup.Add(1)
+		go func(rssModule *RSSUpdateModule) {
+			defer waitGroup.Done()
+			rssModule.Disable()
+		}(rssModule)
+	}
+
+	waitGroup.Wait()
+}
+
+func (module *FeedManagerModule) bind(parent context.Context) {
+	module.supervisor.bind(parent)
+}
+
+// newRSSModule Creates a module for the feed whose loop stops with the manager's context
+func (module *FeedManagerModule) newRSSModule(feed data.RSSFeed, config data.FeedsConfig, channels map[string]uint64) *RSSUpdateModule {
+
+	rssModule := NewRSSUpdateModule(module.checkDelay, feed, config.Profile(feed), channels, module.queue, module.fetcher, module.store)
+	rssModule.bind(module.supervisor.context())
+
+	return rssModule
+}
+
+func (module *FeedManagerModule) Commands() []*command.Command {
+
+	var typeChoices []*discordgo.ApplicationCommandOptionChoice
+	for _, rssType := range format.Types() {
+		typeChoices = append(typeChoices, &discordgo.ApplicationCommandOptionChoice{Name: string(rssType), Value: string(rssType)})
+	}
+
+	urlOption := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "url",
+		Description: "The URL of the feed",
+		Required:    true,
+	}
+
+	return []*command.Command{
+		{
+			Definition: &discordgo.ApplicationCommand{
+				Name:        "feed",
+				Description: "Manages the RSS feeds",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "add",
+						Description: "Adds a feed after validating it",
+						Options: []*discordgo.ApplicationCommandOption{
+							urlOption,
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "channel",
+								Description: "The channel to post updates to",
+								Required:    true,
+								// Suggested from the channels as they are when typing, so ones added by a reload show up
+								Autocomplete: true,
+							},
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "type",
+								Description: "How to format the updates",
+								Choices:     typeChoices,
+							},
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "title",
+								Description: "The title of the embeds",
+							},
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "color",
+								Description: "The color of the embeds, ex: #E1AD01",
+							},
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "thumbnail",
+								Description: "The thumbnail URL of the embeds",
+							},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "remove",
+						Description: "Removes a feed",
+						Options:     []*discordgo.ApplicationCommandOption{urlOption},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "list",
+						Description: "Lists the feeds",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "pause",
+						Description: "Stops checking a feed for updates",
+						Options:     []*discordgo.ApplicationCommandOption{urlOption},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "resume",
+						Description: "Resumes checking a paused feed for updates",
+						Options:     []*discordgo.ApplicationCommandOption{urlOption},
+					},
+				},
+			},
+			Handler:             module.onFeed,
+			AutocompleteHandler: module.onFeedAutocomplete,
+		},
+	}
+}
+
+// onFeedAutocomplete Suggests the channels whose names contain the typed text
+func (module *FeedManagerModule) onFeedAutocomplete(options command.Options) []*discordgo.ApplicationCommandOptionChoice {
+
+	name, text, ok := options.Focused()
+	if !ok || name != "channel" {
+		return nil
+	}
+
+	text = strings.ToLower(text)
+
+	module.mutex.Lock()
+
+	var channelChoices []*discordgo.ApplicationCommandOptionChoice
+	for channelName := range module.channels {
+		if strings.Contains(strings.ToLower(channelName), text) {
+			channelChoices = append(channelChoices, &discordgo.ApplicationCommandOptionChoice{Name: channelName, Value: channelName})
+		}
+	}
+
+	module.mutex.Unlock()
+
+	sort.Slice(channelChoices, func(i, j int) bool {
+		return channelChoices[i].Name < channelChoices[j].Name
+	})
+
+	return channelChoices
+}
+
+func (module *FeedManagerModule) onFeed(session *discordgo.Session, interaction *discordgo.InteractionCreate, options command.Options) error {
+
+	subcommand, subOptions, ok := options.Subcommand()
+	if !ok {
+		return fmt.Errorf("missing subcommand")
+	}
+
+	if subcommand == "list" {
+		return command.RespondEphemeral(session, interaction, module.listMessage())
+	}
+
+	if !command.HasPermission(interaction, discordgo.PermissionManageServer) {
+		return command.RespondEphemeral(session, interaction, "You need the Manage Server permission to change feeds")
+	}
+
+	feedURL, err := subOptions.RequireString("url")
+	if err != nil {
+		return err
+	}
+
+	switch subcommand {
+
+	case "add":
+
+		// Validating the feed requires pulling it, which can take longer than Discord waits for a response
+		err = command.Defer(session, interaction, true)
+		if err != nil {
+			return err
+		}
+
+		err = module.AddFeed(newFeedFromOptions(feedURL, subOptions))
+		if err != nil {
+			return command.EditResponse(session, interaction, fmt.Sprintf("Failed to add feed: %v", err))
+		}
+
+		return command.EditResponse(session, interaction, fmt.Sprintf("Added <%s>", feedURL))
+
+	case "remove":
+		err = module.RemoveFeed(feedURL)
+	case "pause":
+		err = module.SetPaused(feedURL, true)
+	case "resume":
+		err = module.SetPaused(feedURL, false)
+	default:
+		return fmt.Errorf("unknown subcommand %q", subcommand)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return command.RespondEphemeral(session, interaction, fmt.Sprintf("Done: %s <%s>", subcommand, feedURL))
+}
+
+func newFeedFromOptions(feedURL string, options command.Options) data.RSSFeed {
+
+	feed := data.RSSFeed{FeedURL: feedURL}
+	feed.ChannelName, _ = options.String("channel")
+
+	if rssType, ok := options.String("type"); ok {
+		feedType := data.RSSType(rssType)
+		feed.Type = &feedType
+	}
+
+	if title, ok := options.String("title"); ok {
+		feed.Title = &title
+	}
+
+	if color, ok := options.String("color"); ok {
+		feed.Color = &color
+	}
+
+	if thumbnail, ok := options.String("thumbnail"); ok {
+		feed.ThumbnailURL = &thumbnail
+	}
+
+	return feed
+}
+
+// config The feeds and profiles as they are saved, requires the lock
+func (module *FeedManagerModule) config() data.FeedsConfig {
+	return data.FeedsConfig{Profiles: module.profiles, Feeds: module.feeds}
+}
+
+// AddFeed Validates the feed by pulling it once, then starts it and saves it to the feeds file
+func (module *FeedManagerModule) AddFeed(feed data.RSSFeed) error {
+
+	parsedURL, err := url.Parse(feed.FeedURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return fmt.Errorf("invalid feed URL: %s", feed.FeedURL)
+	}
+
+	module.mutex.Lock()
+	_, exists := module.rssModules[feed.FeedURL]
+	_, isKnownChannel := module.channels[feed.ChannelName]
+	channels := module.channels
+	config := module.config()
+	module.mutex.Unlock()
+
+	if !isKnownChannel {
+		return fmt.Errorf("unknown channel: %s", feed.ChannelName)
+	}
+
+	// The options are typed by hand, ex: a color, so they get the checks the feeds file gets
+	err = data.FeedsConfig{Profiles: config.Profiles, Feeds: []data.RSSFeed{feed}}.Validate()
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return fmt.Errorf("feed already exists: %s", feed.FeedURL)
+	}
+
+	rssModule := module.newRSSModule(feed, config, channels)
+
+	_, err = rssModule.pullItems(module.supervisor.context())
+	if err != nil {
+		return fmt.Errorf("failed to validate feed: %w", err)
+	}
+
+	module.mutex.Lock()
+	defer module.mutex.Unlock()
+
+	// Checked again since the lock wasn't held while pulling
+	if _, exists = module.rssModules[feed.FeedURL]; exists {
+		return fmt.Errorf("feed already exists: %s", feed.FeedURL)
+	}
+
+	module.feeds = append(module.feeds, feed)
+	module.rssModules[feed.FeedURL] = rssModule
+
+	if module.supervisor.isRunning() {
+		rssModule.Enable()
+	}
+
+	return module.saveFeeds()
+}
+
+func (module *FeedManagerModule) RemoveFeed(feedURL string) error {
+
+	module.mutex.Lock()
+	defer module.mutex.Unlock()
+
+	index := module.indexOf(feedURL)
+	if index == -1 {
+		return fmt.Errorf("unknown feed: %s", feedURL)
+	}
+
+	module.rssModules[feedURL].Disable()
+	delete(module.rssModules, feedURL)
+
+	module.feeds = append(module.feeds[:index], module.feeds[index+1:]...)
+
+	return module.saveFeeds()
+}
+
+// SetPaused Disables or enables the feed's module and remembers it in the feeds file
+func (module *FeedManagerModule) SetPaused(feedURL string, isPaused bool) error {
+
+	module.mutex.Lock()
+	defer module.mutex.Unlock()
+
+	index := module.indexOf(feedURL)
+	if index == -1 {
+		return fmt.Errorf("unknown feed: %s", feedURL)
+	}
+
+	module.feeds[index].Paused = isPaused
+
+	rssModule := module.rssModules[feedURL]
+	if isPaused {
+		rssModule.Disable()
+	} else if module.supervisor.isRunning() {
+		rssModule.Enable()
+	}
+
+	return module.saveFeeds()
+}
+
+func (module *FeedManagerModule) listMessage() string {
+
+	module.mutex.Lock()
+	defer module.mutex.Unlock()
+
+	if len(module.feeds) == 0 {
+		return "There are no feeds"
+	}
+
+	var builder strings.Builder
+
+	for _, feed := range module.feeds {
+
+		rssType := "Default"
+		if feed.Type != nil {
+			rssType = string(*feed.Type)
+		}
+
+		line := fmt.Sprintf("<%s> → %s (%s)", feed.FeedURL, feed.ChannelName, rssType)
+		if feed.Paused {
+			line += " [paused]"
+		}
+
+		// Discord's message limit
+		if builder.Len()+len(line)+1 > 2000 {
+			break
+		}
+
+		builder.WriteString(line + "\n")
+	}
+
+	return builder.String()
+}
+
+func (module *FeedManagerModule) indexOf(feedURL string) int {
+
+	for i, feed := range module.feeds {
+		if feed.FeedURL == feedURL {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (module *FeedManagerModule) saveFeeds() error {
+
+	err := data.WriteRSSFeeds(module.feedsFilePath, module.config())
+	if err != nil {
+		return err
+	}
+
+	// So our own write doesn't trigger a reload
+	module.rememberConfigModTimes()
+
+	return nil
+}
+
+// Reload Reads the feeds and channels files again and applies the differences to the running feeds
+func (module *FeedManagerModule) Reload() error {
+
+	config, err := data.ReadRSSFeeds(module.feedsFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to reload: %w", err)
+	}
+
+	channels, err := data.ReadChannels(module.channelsFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to reload: %w", err)
+	}
+
+	module.mutex.Lock()
+	defer module.mutex.Unlock()
+
+	module.applyConfig(config, channels)
+	module.rememberConfigModTimes()
+
+	return nil
+}
+
+// applyConfig Starts new feeds, restarts changed ones and disables removed ones, unchanged feeds keep running
+func (module *FeedManagerModule) applyConfig(config data.FeedsConfig, channels map[string]uint64) {
+
+	oldConfig := module.config()
+
+	oldFeeds := map[string]data.RSSFeed{}
+	for _, feed := range module.feeds {
+		oldFeeds[feed.FeedURL] = feed
+	}
+
+	newFeedURLs := map[string]bool{}
+
+	for _, feed := range config.Feeds {
+
+		newFeedURLs[feed.FeedURL] = true
+
+		oldFeed, existed := oldFeeds[feed.FeedURL]
+		channelChanged := module.channels[feed.ChannelName] != channels[feed.ChannelName]
+		profileChanged := !reflect.DeepEqual(oldConfig.Profile(oldFeed), config.Profile(feed))
+
+		if existed && !channelChanged && !profileChanged && reflect.DeepEqual(oldFeed, feed) {
+			continue
+		}
+
+		if existed {
+			log.Printf("Restarting changed feed: %s", feed.FeedURL)
+			module.rssModules[feed.FeedURL].Disable()
+		} else {
+			log.Printf("Starting new feed: %s", feed.FeedURL)
+		}
+
+		rssModule := module.newRSSModule(feed, config, channels)
+		module.rssModules[feed.FeedURL] = rssModule
+
+		if module.supervisor.isRunning() && !feed.Paused {
+			rssModule.Enable()
+		}
+	}
+
+	for feedURL, rssModule := range module.rssModules {
+		if !newFeedURLs[feedURL] {
+			log.Printf("Disabling removed feed: %s", feedURL)
+			rssModule.Disable()
+			delete(module.rssModules, feedURL)
+		}
+	}
+
+	module.feeds = config.Feeds
+	module.profiles = config.Profiles
+	module.channels = channels
+}
+
+func (module *FeedManagerModule) watchTask(ctx context.Context) {
+
+	for sleepContext(ctx, configWatchDelay) {
+
+		if !module.configChanged() {
+			continue
+		}
+
+		err := module.Reload()
+		if err != nil {
+			// Keep running the last valid config
+			log.Printf("%v", err)
+		}
+	}
+}
+
+func (module *FeedManagerModule) configChanged() bool {
+
+	module.mutex.Lock()
+	defer module.mutex.Unlock()
+
+	for _, filePath := range []string{module.feedsFilePath, module.channelsFilePath} {
+
+		info, err := os.Stat(filePath)
+		if err != nil {
+			continue
+		}
+
+		if !info.ModTime().Equal(module.configModTimes[filePath]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (module *FeedManagerModule) rememberConfigModTimes() {
+	for _, filePath := range []string{module.feedsFilePath, module.channelsFilePath} {
+		if info, err := os.Stat(filePath); err == nil {
+			module.configModTimes[filePath] = info.ModTime()
+		}
+	}
+}
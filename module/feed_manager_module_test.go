@@ -0,0 +1,225 @@
+package module
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"privateInfoBot/command"
+	"privateInfoBot/data"
+	"privateInfoBot/fetch"
+	"privateInfoBot/store"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestFeedManager(test *testing.T) *FeedManagerModule {
+
+	config := data.FeedsConfig{
+		Profiles: map[string]data.TransportProfile{"reddit": {UserAgent: "Mozilla/5.0", DisableHTTP2: true}},
+		Feeds: []data.RSSFeed{
+			{ChannelName: "linuxUpdates", FeedURL: "https://www.kernel.org/feeds/kdist.xml"},
+			{ChannelName: "aiNews", FeedURL: "https://openai.com/blog/rss/"},
+		},
+	}
+
+	channels := map[string]uint64{"linuxUpdates": 1, "aiNews": 2}
+
+	// Not enabled, so nothing gets pulled
+	module := NewFeedManagerModule(filepath.Join(test.TempDir(), "rssFeeds.json"), filepath.Join(test.TempDir(), "channels.json"), config, channels, time.Minute, nil, nil, nil)
+	for _, feed := range config.Feeds {
+		module.rssModules[feed.FeedURL] = NewRSSUpdateModule(time.Minute, feed, config.Profile(feed), channels, nil, nil, nil)
+	}
+
+	return module
+}
+
+func TestFeedManagerModule_RemoveFeed(test *testing.T) {
+
+	module := newTestFeedManager(test)
+
+	assert.NoError(test, module.RemoveFeed("https://openai.com/blog/rss/"))
+	assert.Error(test, module.RemoveFeed("https://openai.com/blog/rss/"))
+
+	saved, err := data.ReadRSSFeeds(module.feedsFilePath)
+	assert.NoError(test, err)
+	assert.Equal(test, []data.RSSFeed{{ChannelName: "linuxUpdates", FeedURL: "https://www.kernel.org/feeds/kdist.xml"}}, saved.Feeds)
+	assert.Contains(test, saved.Profiles, "reddit", "saving shouldn't drop the profiles")
+}
+
+func TestFeedManagerModule_SetPaused(test *testing.T) {
+
+	module := newTestFeedManager(test)
+
+	assert.NoError(test, module.SetPaused("https://www.kernel.org/feeds/kdist.xml", true))
+	assert.Error(test, module.SetPaused("https://example.com/unknown.xml", true))
+
+	saved, err := data.ReadRSSFeeds(module.feedsFilePath)
+	assert.NoError(test, err)
+	assert.True(test, saved.Feeds[0].Paused)
+	assert.False(test, saved.Feeds[1].Paused)
+	assert.Contains(test, module.listMessage(), "[paused]")
+}
+
+func TestFeedManagerModule_AddFeed_invalid(test *testing.T) {
+
+	module := newTestFeedManager(test)
+
+	assert.Error(test, module.AddFeed(data.RSSFeed{ChannelName: "aiNews", FeedURL: "not a url"}))
+	assert.Error(test, module.AddFeed(data.RSSFeed{ChannelName: "unknown", FeedURL: "https://example.com/feed"}))
+	assert.Error(test, module.AddFeed(data.RSSFeed{ChannelName: "aiNews", FeedURL: "https://openai.com/blog/rss/"}))
+
+	unknownProfile := "unknown"
+	assert.Error(test, module.AddFeed(data.RSSFeed{ChannelName: "aiNews", FeedURL: "https://example.com/feed", Transport: &unknownProfile}))
+
+	badColor := "#nope"
+	assert.Error(test, module.AddFeed(data.RSSFeed{ChannelName: "aiNews", FeedURL: "https://example.com/feed", Color: &badColor}))
+}
+
+func TestFeedManagerModule_AddFeed(test *testing.T) {
+
+	var feedPulls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+
+		if request.URL.Path != "/feed.xml" {
+			_, _ = writer.Write([]byte(`<html><body>Not a feed</body></html>`))
+			return
+		}
+
+		atomic.AddInt32(&feedPulls, 1)
+		_, _ = writer.Write([]byte(`<?xml version="1.0"?><rss version="2.0"><channel><title>News</title><item><guid>first</guid><title>First</title></item></channel></rss>`))
+	}))
+
+	defer server.Close()
+
+	fetcher := fetch.NewFetcher()
+	fetcher.MinInterval = 0
+
+	feedsFilePath := filepath.Join(test.TempDir(), "rssFeeds.json")
+
+	module := NewFeedManagerModule(feedsFilePath, filepath.Join(test.TempDir(), "channels.json"), data.FeedsConfig{}, map[string]uint64{"news": 1}, time.Hour, nil, fetcher, store.NewJSONStore(test.TempDir()))
+	module.Enable()
+	defer module.Disable()
+
+	feedURL := server.URL + "/feed.xml"
+
+	assert.NoError(test, module.AddFeed(data.RSSFeed{ChannelName: "news", FeedURL: feedURL}))
+	assert.GreaterOrEqual(test, atomic.LoadInt32(&feedPulls), int32(1), "the feed should be pulled to validate it")
+
+	module.mutex.Lock()
+	rssModule, ok := module.rssModules[feedURL]
+	module.mutex.Unlock()
+
+	assert.True(test, ok)
+	assert.True(test, rssModule.IsEnabled(), "the feed should be started since the manager is running")
+
+	saved, err := data.ReadRSSFeeds(feedsFilePath)
+	assert.NoError(test, err)
+	assert.Equal(test, []data.RSSFeed{{ChannelName: "news", FeedURL: feedURL}}, saved.Feeds)
+
+	// A page that isn't a feed fails to validate, so it isn't added
+	assert.Error(test, module.AddFeed(data.RSSFeed{ChannelName: "news", FeedURL: server.URL + "/page.html"}))
+
+	saved, err = data.ReadRSSFeeds(feedsFilePath)
+	assert.NoError(test, err)
+	assert.Len(test, saved.Feeds, 1)
+}
+
+func TestFeedManagerModule_applyConfig(test *testing.T) {
+
+	module := newTestFeedManager(test)
+
+	kernelModule := module.rssModules["https://www.kernel.org/feeds/kdist.xml"]
+	openAIModule := module.rssModules["https://openai.com/blog/rss/"]
+
+	title := "Kernel updates found!"
+
+	module.applyConfig(
+		data.FeedsConfig{Feeds: []data.RSSFeed{
+			{ChannelName: "linuxUpdates", FeedURL: "https://www.kernel.org/feeds/kdist.xml"},
+			{ChannelName: "aiNews", FeedURL: "https://openai.com/blog/rss/", Title: &title},
+			{ChannelName: "aiNews", FeedURL: "https://example.com/feed"},
+		}},
+		map[string]uint64{"linuxUpdates": 1, "aiNews": 2},
+	)
+
+	assert.Same(test, kernelModule, module.rssModules["https://www.kernel.org/feeds/kdist.xml"], "unchanged feeds should keep running")
+	assert.NotSame(test, openAIModule, module.rssModules["https://openai.com/blog/rss/"], "changed feeds should be restarted")
+	assert.Equal(test, &title, module.rssModules["https://openai.com/blog/rss/"].Feed().Title)
+	assert.Contains(test, module.rssModules, "https://example.com/feed")
+
+	// Removing a feed and moving a channel
+	kernelModule = module.rssModules["https://www.kernel.org/feeds/kdist.xml"]
+
+	module.applyConfig(
+		data.FeedsConfig{Feeds: []data.RSSFeed{
+			{ChannelName: "linuxUpdates", FeedURL: "https://www.kernel.org/feeds/kdist.xml"},
+		}},
+		map[string]uint64{"linuxUpdates": 3},
+	)
+
+	assert.Len(test, module.rssModules, 1)
+	assert.NotSame(test, kernelModule, module.rssModules["https://www.kernel.org/feeds/kdist.xml"], "feeds with a changed channel should be restarted")
+
+	// Changing the profile a feed uses
+	profileName := "slow"
+
+	module.applyConfig(
+		data.FeedsConfig{
+			Profiles: map[string]data.TransportProfile{profileName: {UserAgent: "privateInfoBot"}},
+			Feeds:    []data.RSSFeed{{ChannelName: "linuxUpdates", FeedURL: "https://www.kernel.org/feeds/kdist.xml", Transport: &profileName}},
+		},
+		map[string]uint64{"linuxUpdates": 3},
+	)
+
+	kernelModule = module.rssModules["https://www.kernel.org/feeds/kdist.xml"]
+
+	module.applyConfig(
+		data.FeedsConfig{
+			Profiles: map[string]data.TransportProfile{profileName: {UserAgent: "privateInfoBot/2"}},
+			Feeds:    []data.RSSFeed{{ChannelName: "linuxUpdates", FeedURL: "https://www.kernel.org/feeds/kdist.xml", Transport: &profileName}},
+		},
+		map[string]uint64{"linuxUpdates": 3},
+	)
+
+	assert.NotSame(test, kernelModule, module.rssModules["https://www.kernel.org/feeds/kdist.xml"], "feeds with a changed profile should be restarted")
+}
+
+func TestFeedManagerModule_onFeedAutocomplete(test *testing.T) {
+
+	module := newTestFeedManager(test)
+
+	// channelOptions The options of /feed add while the channel is being typed
+	channelOptions := func(text string) command.Options {
+		return command.ParseOptions([]*discordgo.ApplicationCommandInteractionDataOption{
+			{
+				Name: "add",
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{
+					{Name: "channel", Type: discordgo.ApplicationCommandOptionString, Value: text, Focused: true},
+				},
+			},
+		})
+	}
+
+	choiceNames := func(choices []*discordgo.ApplicationCommandOptionChoice) []string {
+
+		var names []string
+		for _, choice := range choices {
+			names = append(names, choice.Name)
+		}
+
+		return names
+	}
+
+	assert.Equal(test, []string{"aiNews", "linuxUpdates"}, choiceNames(module.onFeedAutocomplete(channelOptions(""))))
+	assert.Equal(test, []string{"linuxUpdates"}, choiceNames(module.onFeedAutocomplete(channelOptions("LINUX"))))
+
+	// A channel added by a reload is suggested without registering the command again
+	module.applyConfig(data.FeedsConfig{}, map[string]uint64{"linuxUpdates": 1, "aiNews": 2, "longevityNews": 3})
+
+	assert.Equal(test, []string{"aiNews", "linuxUpdates", "longevityNews"}, choiceNames(module.onFeedAutocomplete(channelOptions(""))))
+}
@@ -0,0 +1,64 @@
+package module
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/mmcdole/gofeed"
+	"privateInfoBot/data"
+	"strings"
+)
+
+// identityFallbacks The strategies tried, in order, when the configured one has nothing to go by
+var identityFallbacks = []data.IdentityStrategy{data.GUIDIdentity, data.LinkIdentity, data.ContentHashIdentity}
+
+// itemIdentity Returns a stable identity for the item, prefixed with the strategy that produced it so they can't collide
+func itemIdentity(item *gofeed.Item, strategy data.IdentityStrategy) string {
+
+	if identity := identityFor(item, strategy); identity != "" {
+		return string(strategy) + ":" + identity
+	}
+
+	for _, fallback := range identityFallbacks {
+		if identity := identityFor(item, fallback); identity != "" {
+			return string(fallback) + ":" + identity
+		}
+	}
+
+	// Unreachable since the hash is never empty
+	return ""
+}
+
+func identityFor(item *gofeed.Item, strategy data.IdentityStrategy) string {
+
+	switch strategy {
+
+	case data.GUIDIdentity:
+		return strings.TrimSpace(item.GUID)
+	case data.LinkIdentity:
+		return strings.TrimSpace(item.Link)
+	case data.TitleIdentity:
+		return strings.TrimSpace(item.Title)
+	case data.ContentHashIdentity:
+		return contentHash(item)
+
+	}
+
+	return ""
+}
+
+// contentHash Hashes the title and content, ignoring differences in case and whitespace
+func contentHash(item *gofeed.Item) string {
+
+	content := item.Content
+	if content == "" {
+		content = item.Description
+	}
+
+	hash := sha256.Sum256([]byte(normalizeText(item.Title) + "\n" + normalizeText(content)))
+
+	return hex.EncodeToString(hash[:])
+}
+
+func normalizeText(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
@@ -0,0 +1,71 @@
+package module
+
+import (
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"privateInfoBot/data"
+	"testing"
+)
+
+func TestItemIdentity(test *testing.T) {
+
+	full := &gofeed.Item{GUID: "t3_abc", Link: "https://example.com/a", Title: "A title", Content: "Some content"}
+	noGUID := &gofeed.Item{Link: "https://example.com/a", Title: "A title"}
+	titleOnly := &gofeed.Item{Title: "A title"}
+
+	tests := []struct {
+		testName string
+		item     *gofeed.Item
+		strategy data.IdentityStrategy
+		expected string
+	}{
+		{testName: "guid", item: full, strategy: data.GUIDIdentity, expected: "guid:t3_abc"},
+		{testName: "link", item: full, strategy: data.LinkIdentity, expected: "link:https://example.com/a"},
+		{testName: "title", item: full, strategy: data.TitleIdentity, expected: "title:A title"},
+		{testName: "guidFallsBackToLink", item: noGUID, strategy: data.GUIDIdentity, expected: "link:https://example.com/a"},
+		{testName: "linkFallsBackToHash", item: titleOnly, strategy: data.LinkIdentity, expected: "hash:" + contentHash(titleOnly)},
+	}
+
+	for _, testData := range tests {
+		test.Run(testData.testName, func(test *testing.T) {
+			assert.Equal(test, testData.expected, itemIdentity(testData.item, testData.strategy))
+		})
+	}
+}
+
+func TestContentHash_normalizes(test *testing.T) {
+
+	original := &gofeed.Item{Title: "Linux 6.0 released", Description: "<p>The  new kernel</p>"}
+	reformatted := &gofeed.Item{Title: "  linux 6.0 Released\n", Description: "<p>The new\tkernel</p>"}
+	edited := &gofeed.Item{Title: "Linux 6.0 released", Description: "<p>The newest kernel</p>"}
+
+	assert.Equal(test, contentHash(original), contentHash(reformatted))
+	assert.NotEqual(test, contentHash(original), contentHash(edited))
+}
+
+func TestRSSUpdateModule_difference_usesIdentity(test *testing.T) {
+
+	module := &RSSUpdateModule{}
+
+	oldItems := []*gofeed.Item{{GUID: "1", Title: "Reposted title"}, {GUID: "2", Title: "Original title"}}
+	newItems := []*gofeed.Item{{GUID: "3", Title: "Reposted title"}, {GUID: "2", Title: "Edited title"}}
+
+	// A new GUID with an old title is new, an old GUID with an edited title isn't
+	assert.Equal(test, []*gofeed.Item{newItems[0]}, module.difference(oldItems, newItems))
+}
+
+func TestParseRSSState(test *testing.T) {
+
+	legacy, err := parseRSSState([]byte(`[{"title": "Old item", "guid": "1"}]`))
+	assert.NoError(test, err)
+	assert.Equal(test, 1, legacy.Version)
+	assert.Equal(test, "1", legacy.Items[0].GUID)
+
+	current, err := parseRSSState([]byte(`{"version": 2, "identity": "link", "items": [{"title": "New item"}]}`))
+	assert.NoError(test, err)
+	assert.Equal(test, data.LinkIdentity, current.Identity)
+	assert.Equal(test, "New item", current.Items[0].Title)
+
+	_, err = parseRSSState([]byte(`{"version": 99, "items": []}`))
+	assert.Error(test, err)
+}
@@ -1,50 +0,0 @@
-package module
-
-/*
-import (
-	"fmt"
-	"github.com/bwmarrin/discordgo"
-	"github.com/stretchr/testify/assert"
-	"testing"
-	"time"
-)
-
-func TestLongevityIORoadmapUpdateModule_pullItems(test *testing.T) {
-	type fields struct {
-		isEnabled  bool
-		checkDelay time.Duration
-		channels   map[string]uint64
-		lastItems  []*LongevityChangeLogEntry
-		discord    *discordgo.Session
-	}
-	testsData := []struct {
-		testName string
-		fields   fields
-		want     []*LongevityChangeLogEntry
-		wantErr  assert.ErrorAssertionFunc
-	}{
-
-		{
-			testName: "Basic",
-		},
-		// TODO: Add test cases.
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			module := &LongevityIORoadmapUpdateModule{
-				isEnabled:  tt.fields.isEnabled,
-				checkDelay: tt.fields.checkDelay,
-				channels:   tt.fields.channels,
-				lastItems:  tt.fields.lastItems,
-				discord:    tt.fields.discord,
-			}
-			got, err := module.pullItems()
-			if !tt.wantErr(t, err, fmt.Sprintf("pullItems()")) {
-				return
-			}
-			assert.Equalf(t, tt.want, got, "pullItems()")
-		})
-	}
-}
-*/
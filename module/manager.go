@@ -0,0 +1,90 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// contextBinder Modules with a supervised update loop, their loops derive from the Manager's context
+type contextBinder interface {
+	bind(parent context.Context)
+}
+
+// Manager Owns the modules, cancelling and waiting for all of them on Shutdown
+type Manager struct {
+	mutex   sync.Mutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+	modules []Module
+}
+
+func NewManager(parent context.Context) *Manager {
+
+	ctx, cancel := context.WithCancel(parent)
+
+	return &Manager{
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Add Adds the module, it still has to be enabled
+func (manager *Manager) Add(modules ...Module) {
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	for _, module := range modules {
+
+		if binder, ok := module.(contextBinder); ok {
+			binder.bind(manager.ctx)
+		}
+
+		manager.modules = append(manager.modules, module)
+	}
+}
+
+func (manager *Manager) Modules() []Module {
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	return append([]Module(nil), manager.modules...)
+}
+
+func (manager *Manager) EnableAll() {
+	for _, module := range manager.Modules() {
+		module.Enable()
+	}
+}
+
+// Shutdown Cancels every module and waits for in-flight posts and saves to finish, up to the timeout
+func (manager *Manager) Shutdown(timeout time.Duration) error {
+
+	manager.cancel()
+
+	var waitGroup sync.WaitGroup
+
+	for _, module := range manager.Modules() {
+		waitGroup.Add(1)
+		go func(module Module) {
+			defer waitGroup.Done()
+			module.Disable()
+		}(module)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		waitGroup.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("modules didn't stop within %v", timeout)
+	}
+}
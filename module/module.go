@@ -5,3 +5,6 @@ type Module interface {
 	Enable()
 	Disable()
 }
+
+// StatePrefixes The key prefixes the modules save their state under, which is what gets migrated between stores
+var StatePrefixes = []string{"RSS/", "Scrape/", "PageChange/"}
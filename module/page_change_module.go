@@ -0,0 +1,353 @@
+package module
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"io"
+	"privateInfoBot/data"
+	"privateInfoBot/delivery"
+	"privateInfoBot/fetch"
+	"privateInfoBot/format"
+	"privateInfoBot/store"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// diffContext How many unchanged lines are shown around the changed ones
+const diffContext = 2
+
+var sourceLineBreaks = strings.NewReplacer("\r", " ", "\n", " ")
+
+// pageSnapshot The page's text as of the last check, a line per block of text
+type pageSnapshot struct {
+	Lines     []string  `json:"lines"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+// PageChangeModule Posts a diff of a page's text when it changes, for pages without any structure to scrape
+type PageChangeModule struct {
+	supervisor supervisor
+	checkDelay time.Duration
+	page       data.PageWatch
+	channelID  uint64
+	snapshot   *pageSnapshot
+	queue      *delivery.Queue
+	fetcher    *fetch.Fetcher
+	store      store.Store
+	// validators and pulledLines are from the last full download, reused when the page replies 304
+	validators  fetch.Validators
+	pulledLines []string
+}
+
+func NewPageChangeModule(
+	checkDelay time.Duration,
+	page data.PageWatch,
+	channels map[string]uint64,
+	queue *delivery.Queue,
+	fetcher *fetch.Fetcher,
+	stateStore store.Store,
+) *PageChangeModule {
+	return &PageChangeModule{
+		supervisor: newSupervisor(page.Name),
+		checkDelay: checkDelay,
+		page:       page,
+		channelID:  channels[page.ChannelName],
+		queue:      queue,
+		fetcher:    fetcher,
+		store:      stateStore,
+	}
+}
+
+func (module *PageChangeModule) IsEnabled() bool {
+	return module.supervisor.isRunning()
+}
+
+func (module *PageChangeModule) Enable() {
+	module.supervisor.start(module.updateTask)
+}
+
+func (module *PageChangeModule) Disable() {
+	module.supervisor.stop()
+}
+
+func (module *PageChangeModule) bind(parent context.Context) {
+	module.supervisor.bind(parent)
+}
+
+func (module *PageChangeModule) updateTask(ctx context.Context) {
+	runUpdateLoop(ctx, module.page.Name, module.checkDelay, module.loadSnapshot, module.check)
+}
+
+// loadSnapshot Reads the snapshot saved by the last change, without one the next check takes it
+func (module *PageChangeModule) loadSnapshot() error {
+
+	module.snapshot = nil
+
+	var snapshot pageSnapshot
+
+	found, err := store.GetJson(module.store, module.stateKey(), &snapshot)
+	if err != nil {
+		return errors.Wrap(err, "loadSnapshot error")
+	}
+
+	if found {
+		module.snapshot = &snapshot
+	}
+
+	return nil
+}
+
+// check Pulls the page and posts a diff if it changed, saving the snapshot it's compared with next time
+func (module *PageChangeModule) check(ctx context.Context) (shouldDisable bool, err error) {
+
+	if onRejected(module.queue, module.stateKey(), module.page.Name, module.page.GetErrorPolicy()) {
+		return true, nil
+	}
+
+	lines, err := module.pullLines(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	shouldDisable, changed := module.checkLines(lines, time.Now())
+
+	if changed {
+		err = module.saveSnapshot()
+		if err != nil {
+			shouldDisable = onSaveError(module.page.GetErrorPolicy(), err) || shouldDisable
+		}
+	}
+
+	return shouldDisable, nil
+}
+
+// checkLines Posts a diff if the lines changed since the snapshot, returning whether the error policy disables the module and whether the snapshot changed.
+// The first check only takes a snapshot, and a diff that failed to post keeps the old snapshot so it's posted again on the next check
+func (module *PageChangeModule) checkLines(lines []string, now time.Time) (shouldDisable bool, changed bool) {
+
+	if module.snapshot == nil {
+		module.snapshot = &pageSnapshot{Lines: lines, ChangedAt: now}
+		return false, true
+	}
+
+	diff := module.diff(module.snapshot.Lines, lines)
+	if len(diff) == 0 {
+		return false, false
+	}
+
+	err := module.postDiff(diff, now)
+	if err != nil {
+
+		skip, disable := onPostError(module.page.Name, module.page.GetErrorPolicy(), err)
+
+		// Keeping the old snapshot posts the diff again, taking the new one below skips it
+		if !skip {
+			return disable, false
+		}
+	}
+
+	module.snapshot = &pageSnapshot{Lines: lines, ChangedAt: now}
+
+	return false, true
+}
+
+// diff The unified diff between the lines, without the file headers, empty if they're the same
+func (module *PageChangeModule) diff(oldLines []string, newLines []string) []string {
+
+	withNewlines := func(lines []string) []string {
+
+		result := make([]string, 0, len(lines))
+		for _, line := range lines {
+			result = append(result, line+"\n")
+		}
+
+		return result
+	}
+
+	unifiedDiff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:       withNewlines(oldLines),
+		B:       withNewlines(newLines),
+		Context: diffContext,
+	})
+
+	// Only writing to a string, so it can't fail
+	if err != nil || unifiedDiff == "" {
+		return nil
+	}
+
+	var diffLines []string
+	for _, line := range strings.Split(strings.TrimSuffix(unifiedDiff, "\n"), "\n") {
+		if !strings.HasPrefix(line, "--- ") && !strings.HasPrefix(line, "+++ ") {
+			diffLines = append(diffLines, line)
+		}
+	}
+
+	return diffLines
+}
+
+// postDiff Queues an embed with the diff
+func (module *PageChangeModule) postDiff(diff []string, now time.Time) error {
+
+	embed := &discordgo.MessageEmbed{
+		URL:         module.page.URL,
+		Title:       fmt.Sprintf("%s changed!", module.page.Name),
+		Description: format.CodeBlock("diff", diff),
+		Timestamp:   format.Timestamp(now),
+		Color:       format.EmbedColor(module.page.Color),
+	}
+
+	if module.page.Title != nil {
+		embed.Title = *module.page.Title
+	}
+
+	if module.page.ThumbnailURL != nil {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: *module.page.ThumbnailURL}
+	}
+
+	channelIDString := strconv.FormatUint(module.channelID, 10)
+
+	err := module.queue.Enqueue(module.stateKey(), channelIDString, format.Limit([]discordgo.MessageSend{{Embed: embed}}), true)
+	if err != nil {
+		return fmt.Errorf("postDiff failed channel (%s:%s): %w", module.page.ChannelName, channelIDString, err)
+	}
+
+	return nil
+}
+
+// pullLines Downloads the page and takes out its text, the last pulled lines if the page replied 304.
+// Those aren't the snapshot's, a diff that failed to post is still a diff after a 304
+func (module *PageChangeModule) pullLines(ctx context.Context) ([]string, error) {
+
+	fmt.Printf("(%v) Pulling: %v\n", time.Now().Format("02 Jan 2006 03:04PM MST"), module.page.URL)
+
+	// Without pulled lines a 304 would leave nothing to compare with
+	if module.pulledLines == nil {
+		module.validators = fetch.Validators{}
+	}
+
+	response, err := module.fetcher.Fetch(ctx, module.page.URL, &module.validators, fetch.Options{})
+	if err != nil {
+		return nil, errors.Wrap(err, "pullLines error")
+	}
+
+	if response.NotModified {
+		return module.pulledLines, nil
+	}
+
+	lines, err := pageLines(bytes.NewReader(response.Body), module.page.Selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "pullLines error")
+	}
+
+	module.pulledLines = lines
+
+	return lines, nil
+}
+
+// pageLines The text of the selected elements, or the body, a line per block of text with its whitespace normalized
+func pageLines(reader io.Reader, selector string) ([]string, error) {
+
+	document, err := goquery.NewDocumentFromReader(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get document reader")
+	}
+
+	selection := document.Find("body")
+	if selector != "" {
+		selection = document.Find(selector)
+	}
+
+	// Posting the whole page as removed isn't useful, the selector most likely needs updating
+	if selection.Length() == 0 {
+		return nil, errors.Errorf("nothing matches the selector: %q", selector)
+	}
+
+	var builder strings.Builder
+	for _, node := range selection.Nodes {
+		writePageText(&builder, node)
+		builder.WriteString("\n")
+	}
+
+	var lines []string
+	for _, line := range strings.Split(builder.String(), "\n") {
+		if line = collapseSpaces(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, nil
+}
+
+// writePageText Writes the node's text, block elements on lines of their own
+func writePageText(builder *strings.Builder, node *html.Node) {
+
+	switch node.Type {
+
+	case html.TextNode:
+		// Only block elements break lines, not the page source's own line breaks
+		builder.WriteString(sourceLineBreaks.Replace(node.Data))
+		return
+
+	case html.ElementNode:
+		switch node.DataAtom {
+		case atom.Script, atom.Style, atom.Noscript, atom.Template, atom.Svg, atom.Iframe:
+			return
+		}
+	}
+
+	isBlock := isBlockElement(node)
+	if isBlock {
+		builder.WriteString("\n")
+	}
+
+	// Keeps a table's cells apart on their row's line
+	if node.DataAtom == atom.Td || node.DataAtom == atom.Th {
+		builder.WriteString(" ")
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		writePageText(builder, child)
+	}
+
+	if isBlock {
+		builder.WriteString("\n")
+	}
+}
+
+func isBlockElement(node *html.Node) bool {
+
+	if node.Type != html.ElementNode {
+		return false
+	}
+
+	switch node.DataAtom {
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer, atom.Main, atom.Nav, atom.Aside,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Ul, atom.Ol, atom.Li, atom.Dl, atom.Dt, atom.Dd,
+		atom.Table, atom.Tr, atom.Pre, atom.Blockquote, atom.Br, atom.Hr, atom.Form, atom.Figure, atom.Figcaption:
+		return true
+	}
+
+	return false
+}
+
+func (module *PageChangeModule) saveSnapshot() error {
+
+	err := store.PutJson(module.store, module.stateKey(), module.snapshot)
+	if err != nil {
+		return errors.Wrap(err, "failed to saveSnapshot")
+	}
+
+	return nil
+}
+
+func (module *PageChangeModule) stateKey() string {
+	return "PageChange/" + module.page.Name
+}
@@ -0,0 +1,205 @@
+package module
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"privateInfoBot/data"
+	"privateInfoBot/delivery"
+	"privateInfoBot/fetch"
+	"testing"
+	"time"
+)
+
+func TestPageLines(test *testing.T) {
+
+	tests := []struct {
+		testName    string
+		selector    string
+		expected    []string
+		expectError bool
+	}{
+		{
+			testName: "selector",
+			selector: "#releases",
+			expected: []string{
+				"Latest drivers",
+				"Game Ready 526.47 October 27, 2022",
+				"Studio 522.30 October 11, 2022",
+				"Supports Windows 10 and Windows 11.",
+				"Fixed flickering in some games",
+				"Added support for new GPUs",
+			},
+		},
+		{
+			testName: "body",
+			expected: []string{
+				"Home Drivers",
+				"Latest drivers",
+				"Game Ready 526.47 October 27, 2022",
+				"Studio 522.30 October 11, 2022",
+				"Supports Windows 10 and Windows 11.",
+				"Fixed flickering in some games",
+				"Added support for new GPUs",
+				"Copyright 2022",
+			},
+		},
+		{testName: "noMatch", selector: "#missing", expectError: true},
+	}
+
+	for _, testData := range tests {
+		test.Run(testData.testName, func(test *testing.T) {
+
+			page, err := os.Open(filepath.Join("testdata", "page", "drivers.html"))
+			if err != nil {
+				test.Fatal(err)
+			}
+
+			defer page.Close()
+
+			lines, err := pageLines(page, testData.selector)
+			if testData.expectError {
+				assert.Error(test, err)
+				return
+			}
+
+			assert.NoError(test, err)
+			assert.Equal(test, testData.expected, lines)
+		})
+	}
+}
+
+func TestPageChangeModule_checkLines(test *testing.T) {
+
+	queue, err := delivery.NewQueue(test.TempDir(), nil)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	page := data.PageWatch{Name: "drivers", ChannelName: "drivers", URL: "https://example.com/drivers"}
+	module := NewPageChangeModule(time.Minute, page, map[string]uint64{"drivers": 1}, queue, nil, nil)
+
+	now := time.Date(2022, time.October, 27, 12, 0, 0, 0, time.UTC)
+	oldLines := []string{"Latest drivers", "Game Ready 522.25", "Studio 522.30", "Copyright 2022"}
+	newLines := []string{"Latest drivers", "Game Ready 526.47", "Studio 522.30", "Copyright 2022"}
+
+	// The first check only takes a snapshot
+	shouldDisable, changed := module.checkLines(oldLines, now)
+	assert.False(test, shouldDisable)
+	assert.True(test, changed)
+	assert.Empty(test, queue.Pending())
+
+	shouldDisable, changed = module.checkLines(oldLines, now)
+	assert.False(test, shouldDisable)
+	assert.False(test, changed)
+	assert.Empty(test, queue.Pending())
+
+	shouldDisable, changed = module.checkLines(newLines, now)
+	assert.False(test, shouldDisable)
+	assert.True(test, changed)
+	assert.Equal(test, newLines, module.snapshot.Lines)
+	assert.Len(test, queue.Pending(), 1)
+
+	pending := queue.Pending()[0]
+	assert.Equal(test, "1", pending.ChannelID)
+	assert.Equal(test, "drivers changed!", pending.Message.Embeds[0].Title)
+	assert.Equal(test, "https://example.com/drivers", pending.Message.Embeds[0].URL)
+	assert.Equal(test, "```diff\n@@ -1,4 +1,4 @@\n Latest drivers\n-Game Ready 522.25\n+Game Ready 526.47\n Studio 522.30\n Copyright 2022\n```", pending.Message.Embeds[0].Description)
+}
+
+func TestPageChangeModule_checkLinesErrorPolicy(test *testing.T) {
+
+	skip := data.SkipOnError
+	disable := data.DisableOnError
+
+	oldLines := []string{"Game Ready 522.25"}
+	newLines := []string{"Game Ready 526.47"}
+
+	tests := []struct {
+		testName         string
+		errorPolicy      *data.ErrorPolicy
+		expectedDisable  bool
+		expectedChanged  bool
+		expectedSnapshot []string
+	}{
+		{testName: "retry", expectedSnapshot: oldLines},
+		{testName: "skip", errorPolicy: &skip, expectedChanged: true, expectedSnapshot: newLines},
+		{testName: "disable", errorPolicy: &disable, expectedDisable: true, expectedSnapshot: oldLines},
+	}
+
+	for _, testData := range tests {
+		test.Run(testData.testName, func(test *testing.T) {
+
+			queue, _ := blockedQueue(test)
+
+			page := data.PageWatch{Name: "drivers", URL: "https://example.com/drivers", ErrorPolicy: testData.errorPolicy}
+			module := NewPageChangeModule(time.Minute, page, nil, queue, nil, nil)
+			module.snapshot = &pageSnapshot{Lines: oldLines}
+
+			shouldDisable, changed := module.checkLines(newLines, time.Now())
+			assert.Equal(test, testData.expectedDisable, shouldDisable)
+			assert.Equal(test, testData.expectedChanged, changed)
+			assert.Equal(test, testData.expectedSnapshot, module.snapshot.Lines)
+		})
+	}
+}
+
+func TestPageChangeModule_pullLines(test *testing.T) {
+
+	page, err := os.ReadFile(filepath.Join("testdata", "page", "drivers.html"))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	notModified := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+
+		if request.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			writer.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		writer.Header().Set("ETag", `"v1"`)
+		_, _ = writer.Write(page)
+	}))
+
+	defer server.Close()
+
+	fetcher := fetch.NewFetcher()
+	fetcher.MinInterval = 0
+
+	queue, unblock := blockedQueue(test)
+
+	watch := data.PageWatch{Name: "drivers", ChannelName: "drivers", URL: server.URL, Selector: "#releases ul"}
+	module := NewPageChangeModule(time.Minute, watch, map[string]uint64{"drivers": 1}, queue, fetcher, nil)
+	module.snapshot = &pageSnapshot{Lines: []string{"Fixed flickering in some games"}}
+
+	lines, err := module.pullLines(context.Background())
+	assert.NoError(test, err)
+	assert.Equal(test, []string{"Fixed flickering in some games", "Added support for new GPUs"}, lines)
+
+	// Retried by default, so the old snapshot is kept
+	shouldDisable, changed := module.checkLines(lines, time.Now())
+	assert.False(test, shouldDisable)
+	assert.False(test, changed)
+	assert.Empty(test, queue.Pending())
+
+	unblock()
+
+	// The page replies 304 now, the diff that failed is still posted
+	lines, err = module.pullLines(context.Background())
+	assert.NoError(test, err)
+	assert.Equal(test, 1, notModified)
+	assert.Equal(test, []string{"Fixed flickering in some games", "Added support for new GPUs"}, lines)
+
+	shouldDisable, changed = module.checkLines(lines, time.Now())
+	assert.False(test, shouldDisable)
+	assert.True(test, changed)
+	assert.Len(test, queue.Pending(), 1)
+	assert.Equal(test, lines, module.snapshot.Lines)
+}
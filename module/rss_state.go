@@ -0,0 +1,50 @@
+package module
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/json-iterator/go"
+	"github.com/mmcdole/gofeed"
+	"privateInfoBot/data"
+)
+
+// rssStateVersion Version 1 was a bare list of items, before identities were configurable
+const rssStateVersion = 2
+
+// rssState What is saved for a feed between checks
+type rssState struct {
+	Version int `json:"version"`
+	// Identity is the strategy in use when the state was saved
+	Identity data.IdentityStrategy `json:"identity"`
+	Items    []*gofeed.Item        `json:"items"`
+}
+
+// parseRSSState Parses a saved state, migrating version 1 files which were a bare list of items
+func parseRSSState(jsonData []byte) (*rssState, error) {
+
+	if bytes.HasPrefix(bytes.TrimSpace(jsonData), []byte("[")) {
+
+		var items []*gofeed.Item
+
+		err := jsoniter.Unmarshal(jsonData, &items)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse version 1 state: %w", err)
+		}
+
+		// Version 1 compared titles, the items are kept whole so any strategy works on them
+		return &rssState{Version: 1, Identity: data.TitleIdentity, Items: items}, nil
+	}
+
+	state := new(rssState)
+
+	err := jsoniter.Unmarshal(jsonData, state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse state: %w", err)
+	}
+
+	if state.Version > rssStateVersion {
+		return nil, fmt.Errorf("state version %d is newer than supported version %d", state.Version, rssStateVersion)
+	}
+
+	return state, nil
+}
@@ -1,20 +1,18 @@
 package module
 
 import (
-	"crypto/tls"
+	"context"
 	"fmt"
-	"github.com/PuerkitoBio/goquery"
 	"github.com/bwmarrin/discordgo"
-	"github.com/json-iterator/go"
 	"github.com/mmcdole/gofeed"
 	"github.com/pkg/errors"
-	"html"
-	"io/ioutil"
 	"log"
 	"net/http"
-	"os"
-	"path"
 	"privateInfoBot/data"
+	"privateInfoBot/delivery"
+	"privateInfoBot/fetch"
+	"privateInfoBot/format"
+	"privateInfoBot/store"
 	"privateInfoBot/utils"
 	"strconv"
 	"strings"
@@ -22,336 +20,370 @@ import (
 )
 
 type RSSUpdateModule struct {
-	isEnabled  bool
+	supervisor supervisor
 	checkDelay time.Duration
 	rssFeed    data.RSSFeed
+	profile    data.TransportProfile
+	templates  *data.ParsedTemplates
+	versions   *data.ParsedVersionFilter
 	channels   map[string]uint64
 	lastItems  []*gofeed.Item
-	discord    *discordgo.Session
+	seen       *seenSet
+	queue      *delivery.Queue
+	fetcher    *fetch.Fetcher
+	store      store.Store
+	// skipPosting Set when nothing was seen yet, so the first check doesn't flood the channel
+	skipPosting bool
+	// validators and pulledItems are from the last full download, reused when the feed replies 304
+	validators  fetch.Validators
+	pulledItems []*gofeed.Item
 }
 
 func NewRSSUpdateModule(
 	checkDelay time.Duration,
 	rssFeed data.RSSFeed,
+	profile data.TransportProfile,
 	channels map[string]uint64,
-	discord *discordgo.Session,
+	queue *delivery.Queue,
+	fetcher *fetch.Fetcher,
+	stateStore store.Store,
 ) *RSSUpdateModule {
-	return &RSSUpdateModule{
+
+	module := &RSSUpdateModule{
+		supervisor: newSupervisor(rssFeed.FeedURL),
 		checkDelay: checkDelay,
 		rssFeed:    rssFeed,
+		profile:    profile,
 		channels:   channels,
-		discord:    discord,
+		queue:      queue,
+		fetcher:    fetcher,
+		store:      stateStore,
 	}
+
+	// Already validated when the config was read, so this only fails for feeds created in code
+	if rssFeed.Templates != nil {
+
+		templates, err := rssFeed.Templates.Parse()
+		if err != nil {
+			log.Printf("%v", fmt.Errorf("ignoring the templates of %v: %w", rssFeed.FeedURL, err))
+		}
+
+		module.templates = templates
+	}
+
+	if rssFeed.Versions != nil {
+
+		versions, err := rssFeed.Versions.Parse()
+		if err != nil {
+			log.Printf("%v", fmt.Errorf("ignoring the version filter of %v: %w", rssFeed.FeedURL, err))
+		}
+
+		module.versions = versions
+	}
+
+	return module
 }
 
 func (module *RSSUpdateModule) IsEnabled() bool {
-	return module.isEnabled
+	return module.supervisor.isRunning()
 }
 
 func (module *RSSUpdateModule) Enable() {
-	if !module.isEnabled {
-		module.isEnabled = true
-		module.lastItems = module.pullSavedData()
-		go module.updateTask(len(module.lastItems) == 0)
-	}
+	module.supervisor.start(module.updateTask)
 }
 
+// Disable Stops the update loop, waiting for an in-flight post or save to finish
 func (module *RSSUpdateModule) Disable() {
-	module.isEnabled = false
+	module.supervisor.stop()
 }
 
-func (module *RSSUpdateModule) updateTask(skipPostingFirstRun bool) {
-
-	hasSkipped := false
+func (module *RSSUpdateModule) bind(parent context.Context) {
+	module.supervisor.bind(parent)
+}
 
-	for module.isEnabled {
+func (module *RSSUpdateModule) Feed() data.RSSFeed {
+	return module.rssFeed
+}
 
-		pulledItems, err := module.pullItems()
-		if err != nil {
-			log.Printf("%v", fmt.Errorf("failed to pull items for %v: %w", module.rssFeed.FeedURL, err))
-			time.Sleep(module.checkDelay)
-			continue
-		}
+func (module *RSSUpdateModule) updateTask(ctx context.Context) {
+	runUpdateLoop(ctx, module.rssFeed.FeedURL, module.checkDelay, module.loadState, module.check)
+}
 
-		if !hasSkipped && skipPostingFirstRun {
-			hasSkipped = true
-			module.lastItems = pulledItems
-			module.saveLastItems()
-			time.Sleep(module.checkDelay)
-			continue
-		}
+// loadState Reads the items and seen set saved by the last check, the next check only remembers what's there if nothing was seen yet
+func (module *RSSUpdateModule) loadState() error {
 
-		recentUpdates := module.filterRecentUpdates(pulledItems)
-		if recentUpdates != nil {
-			module.postUpdates(recentUpdates)
-		}
+	lastItems, err := module.pullSavedData()
 
-		module.lastItems = pulledItems
-		module.saveLastItems()
+	module.lastItems = lastItems
+	module.seen = module.loadSeenSet(lastItems)
+	module.skipPosting = len(module.seen.Seen) == 0
 
-		time.Sleep(module.checkDelay)
-	}
+	return err
 }
 
-func (module *RSSUpdateModule) postUpdates(items []*gofeed.Item) {
+// check Pulls the feed, posts its new items and saves what was posted
+func (module *RSSUpdateModule) check(ctx context.Context) (shouldDisable bool, err error) {
 
-	var messagesToSend []discordgo.MessageSend
+	if onRejected(module.queue, module.stateKey(), module.rssFeed.FeedURL, module.rssFeed.GetErrorPolicy()) {
+		return true, nil
+	}
 
-	if module.rssFeed.Type != nil {
+	pulledItems, err := module.pullItems(ctx)
+	if err != nil {
+		return false, err
+	}
 
-		switch *module.rssFeed.Type {
+	if module.skipPosting {
+		module.skipPosting = false
+		module.lastItems = pulledItems
+		module.rememberSeen(module.lastItems, time.Now())
+	} else {
+		shouldDisable = module.postNewItems(pulledItems, time.Now())
+	}
 
-		case data.Reddit:
-			messagesToSend = module.itemsToRedditMessages(items)
-		case data.Github:
-			messagesToSend = module.itemsToGithubMessages(items)
-		case data.TitleAndLink:
-			messagesToSend = module.itemsToTitleAndLinkMessages(items)
-		case data.KernelOrgUpdates:
-			messagesToSend = module.itemsToKernelOrgMessages(items)
+	err = module.saveLastItems()
+	if err == nil {
+		err = module.seen.save(module.store, module.seenKey())
+	}
 
-		default:
-			messagesToSend = module.itemsToDefaultMessages(items)
+	if err != nil {
+		shouldDisable = onSaveError(module.rssFeed.GetErrorPolicy(), err) || shouldDisable
+	}
 
-		}
+	return shouldDisable, nil
+}
 
-	}
+// postNewItems Posts the pulled items that weren't seen yet and remembers the ones that posted, returning whether the error policy disables the module.
+// Failed items aren't remembered, unless the policy skips them, so they are seen as new and posted on the next check
+func (module *RSSUpdateModule) postNewItems(pulledItems []*gofeed.Item, now time.Time) (shouldDisable bool) {
 
-	channelID := module.channels[module.rssFeed.ChannelName]
+	var failedItems []*gofeed.Item
 
-	for _, messageToSend := range messagesToSend {
+	var errs []error
 
-		channelIDString := strconv.FormatUint(channelID, 10)
+	recentUpdates, missedUpdates := module.filterRecentUpdates(pulledItems)
 
-		message, err := module.discord.ChannelMessageSendComplex(channelIDString, &messageToSend)
-		if err != nil {
-			log.Fatal(fmt.Errorf("postUpdates failed channel (%s:%s): %w", module.rssFeed.ChannelName, channelIDString, err))
+	// Missed updates are older, so they go first
+	if missedUpdates != nil {
+		digestErr := module.postDigest(missedUpdates)
+		if digestErr != nil {
+			errs = append(errs, digestErr)
+			failedItems = append(failedItems, missedUpdates...)
 		}
+	}
 
-		_, err = module.discord.ChannelMessageCrosspost(channelIDString, message.ID)
-		if err != nil {
-			log.Fatal(fmt.Errorf("postUpdates failed channel (%s:%s): %w", module.rssFeed.ChannelName, channelIDString, err))
+	if recentUpdates != nil {
+		failedRecentItems, postErr := module.postUpdates(recentUpdates)
+		if postErr != nil {
+			errs = append(errs, postErr)
+			failedItems = append(failedItems, failedRecentItems...)
 		}
 	}
-}
-
-func (module *RSSUpdateModule) itemsToRedditMessages(items []*gofeed.Item) (messages []discordgo.MessageSend) {
-
-	for _, item := range items {
 
-		embed := module.embedTemplateForItem(item)
+	err := utils.JoinErrors(errs)
 
-		document, err := goquery.NewDocumentFromReader(strings.NewReader(item.Content))
-		if err != nil {
-			log.Fatal(fmt.Errorf("itemsToRedditMessages failed: %w", err))
-		}
+	if err != nil {
 
-		hyperLink, _ := document.Find("td a").First().Attr("href")
-		imageLink, _ := document.Find("td img").First().Attr("src")
+		var skip bool
+		skip, shouldDisable = onPostError(module.rssFeed.FeedURL, module.rssFeed.GetErrorPolicy(), err)
 
-		if hyperLink != "" {
-			embed.Description = hyperLink
-		}
-		if embed.Image != nil {
-			embed.Image = &discordgo.MessageEmbedImage{URL: imageLink}
+		if skip {
+			failedItems = nil
 		}
-
-		messages = append(messages, discordgo.MessageSend{Embed: embed})
 	}
 
-	return
-}
+	module.lastItems = module.difference(failedItems, pulledItems)
+	module.rememberSeen(module.lastItems, now)
 
-func (module *RSSUpdateModule) itemsToGithubMessages(items []*gofeed.Item) (messages []discordgo.MessageSend) {
+	return shouldDisable
+}
 
-	embed := &discordgo.MessageEmbed{
-		URL: strings.TrimSuffix(module.rssFeed.FeedURL, "/commits/master.atom"),
-	}
+// postUpdates Posts the items, returning the ones that failed to post
+func (module *RSSUpdateModule) postUpdates(items []*gofeed.Item) (failedItems []*gofeed.Item, err error) {
 
-	module.applyTitle(embed)
-	module.applyDescription(embed)
-	module.applyColor(embed)
-	module.applyThumbnail(embed)
+	var errs []error
 
-	field := discordgo.MessageEmbedField{
-		Name:  "New commit messages",
-		Value: "\n",
-	}
+	formatter := format.For(module.rssFeed.Type)
 
-	for i, item := range items {
+	for _, batch := range formatter.Batch(items) {
 
-		if i > 0 {
-			field.Value += "\n\n"
+		messages, batchErr := formatter.Format(module.rssFeed, batch)
+		if batchErr == nil {
+			batchErr = format.ApplyTemplates(module.templates, module.rssFeed, batch, messages)
 		}
 
-		title := strings.TrimFunc(item.Title, func(r rune) bool {
-			return r == ' ' || r == '\n'
-		})
-
-		field.Value += ":green_circle: " + title
-	}
-
-	embed.Fields = []*discordgo.MessageEmbedField{&field}
-	return []discordgo.MessageSend{{Embed: embed}}
-}
-
-func (module *RSSUpdateModule) itemsToTitleAndLinkMessages(items []*gofeed.Item) (messages []discordgo.MessageSend) {
+		if batchErr == nil {
+			batchErr = module.sendMessages(messages)
+		}
 
-	for _, item := range items {
-		messages = append(messages,
-			discordgo.MessageSend{
-				Content: fmt.Sprintf("**%v**\n%v", item.Title, item.Link),
-			},
-		)
+		if batchErr != nil {
+			errs = append(errs, batchErr)
+			failedItems = append(failedItems, batch...)
+		}
 	}
 
-	return
+	return failedItems, utils.JoinErrors(errs)
 }
 
-func (module *RSSUpdateModule) itemsToKernelOrgMessages(items []*gofeed.Item) (messages []discordgo.MessageSend) {
+// postDigest Posts the items as one message listing their links
+func (module *RSSUpdateModule) postDigest(items []*gofeed.Item) error {
 
-	embed := &discordgo.MessageEmbed{
-		URL: module.rssFeed.FeedURL,
+	message, err := format.Digest(module.rssFeed, items)
+	if err == nil {
+		err = module.sendMessages([]discordgo.MessageSend{message})
 	}
 
-	module.applyTitle(embed)
-	module.applyDescription(embed)
-	module.applyColor(embed)
-	module.applyThumbnail(embed)
-
-	field := discordgo.MessageEmbedField{
-		Name:  "New versions",
-		Value: "\n",
+	if err != nil {
+		return fmt.Errorf("postDigest failed: %w", err)
 	}
 
-	for i, item := range items {
+	return nil
+}
 
-		if i > 0 {
-			field.Value += "\n\n"
-		}
+// sendMessages Queues the messages, the queue retries failed deliveries on its own
+func (module *RSSUpdateModule) sendMessages(messages []discordgo.MessageSend) error {
 
-		title := strings.TrimFunc(item.Title, func(r rune) bool {
-			return r == ' ' || r == '\n'
-		})
+	channelID := module.channels[module.rssFeed.ChannelName]
+	channelIDString := strconv.FormatUint(channelID, 10)
 
-		field.Value += ":green_circle: " + title
+	err := module.queue.Enqueue(module.stateKey(), channelIDString, format.Limit(messages), true)
+	if err != nil {
+		return fmt.Errorf("postUpdates failed channel (%s:%s): %w", module.rssFeed.ChannelName, channelIDString, err)
 	}
 
-	embed.Fields = []*discordgo.MessageEmbedField{&field}
-	return []discordgo.MessageSend{{Embed: embed}}
+	return nil
 }
 
-func (module *RSSUpdateModule) itemsToDefaultMessages(items []*gofeed.Item) (messages []discordgo.MessageSend) {
+func (module *RSSUpdateModule) pullItems(ctx context.Context) ([]*gofeed.Item, error) {
 
-	for _, item := range items {
-		messages = append(messages,
-			discordgo.MessageSend{
-				Embed: &discordgo.MessageEmbed{
-					Description: html.UnescapeString(item.Description),
-				},
-			})
-	}
+	fmt.Printf("(%v) Pulling: %v\n", time.Now().Format("02 Jan 2006 03:04PM MST"), module.rssFeed.FeedURL)
 
-	return
-}
+	options := fetch.Options{
+		Header:       http.Header{},
+		DisableHTTP2: module.profile.DisableHTTP2,
+		Proxy:        module.profile.Proxy,
+	}
 
-func (module *RSSUpdateModule) pullItems() ([]*gofeed.Item, error) {
+	for name, value := range module.profile.Headers {
+		options.Header.Set(name, value)
+	}
 
-	fmt.Printf("(%v) Pulling: %v\n", time.Now().Format("02 Jan 2006 03:04PM MST"), module.rssFeed.FeedURL)
+	if module.profile.UserAgent != "" {
+		options.Header.Set("User-Agent", module.profile.UserAgent)
+	}
 
-	client := new(http.Client)
+	// Without the items from last time a 304 would leave nothing to work with
+	if module.pulledItems == nil {
+		module.validators = fetch.Validators{}
+	}
 
-	request, err := http.NewRequest("GET", module.rssFeed.FeedURL, nil)
+	response, err := module.fetcher.Fetch(ctx, module.rssFeed.FeedURL, &module.validators, options)
 	if err != nil {
 		return nil, fmt.Errorf("pullUpdates error: %w", err)
 	}
 
-	isReddit := module.rssFeed.Type != nil && *module.rssFeed.Type == data.Reddit
-
-	// Fix weird Reddit issue "Status Code: 429" https://www.reddit.com/r/redditdev/comments/t8e8hc/getting_nothing_but_429_responses_when_using_go/
-	if isReddit {
-		client.Transport = &http.Transport{
-			TLSNextProto: map[string]func(authority string, c *tls.Conn) http.RoundTripper{},
-		}
-		request.Header.Set("User-Agent", "Mozilla/5.0")
+	if response.NotModified {
+		return module.pulledItems, nil
 	}
 
-	response, err := client.Do(request)
+	rssFeed, err := gofeed.NewParser().ParseString(module.profile.RepairBody(string(response.Body)))
 	if err != nil {
 		return nil, fmt.Errorf("pullUpdates error: %w", err)
 	}
 
-	var rssFeed *gofeed.Feed
+	module.pulledItems = rssFeed.Items
+
+	return rssFeed.Items, nil
+}
 
-	// Repair content type if it is Reddit
-	if isReddit {
+// filterRecentUpdates Splits the unseen items into recent ones and, if catching up, missed ones that are older than the max age.
+// Items left out by the feed's filters are in neither, so they are remembered as seen without being posted
+func (module *RSSUpdateModule) filterRecentUpdates(items []*gofeed.Item) (updates []*gofeed.Item, missed []*gofeed.Item) {
 
-		bodyBytes, err := ioutil.ReadAll(response.Body)
-		if err != nil {
-			return nil, fmt.Errorf("pullUpdates error: %w", err)
-		}
+	now := time.Now()
 
-		bodyText := strings.Replace(string(bodyBytes), "text/html", "application/rss+xml", 1)
+	for _, item := range module.unseen(items) {
 
-		rssFeed, err = gofeed.NewParser().ParseString(bodyText)
-		if err != nil {
-			return nil, fmt.Errorf("pullUpdates error: %w", err)
+		if !module.isFollowed(item) {
+			continue
 		}
-	} else {
-		rssFeed, err = gofeed.NewParser().Parse(response.Body)
-		if err != nil {
-			return nil, fmt.Errorf("pullUpdates error: %w", err)
+
+		if module.isRecent(item, now) {
+			updates = append(updates, item)
+		} else if module.rssFeed.CatchUp {
+			missed = append(missed, item)
 		}
 	}
 
-	err = response.Body.Close()
-	if err != nil {
-		return nil, fmt.Errorf("pullUpdates error: %w", err)
+	return
+}
+
+// isFollowed Whether the feed's version and kernel filters let the item be posted
+func (module *RSSUpdateModule) isFollowed(item *gofeed.Item) bool {
+
+	if module.versions != nil && !module.versions.Matches(format.ReleaseTag(item)) {
+		return false
 	}
 
-	return rssFeed.Items, nil
+	if module.rssFeed.Kernel != nil {
+
+		release, err := format.ParseKernelRelease(item)
+		if err != nil || !module.rssFeed.Kernel.Matches(release.Moniker, release.Version) {
+			return false
+		}
+	}
+
+	return true
 }
 
-func (module *RSSUpdateModule) filterRecentUpdates(items []*gofeed.Item) (updates []*gofeed.Item) {
+// isRecent Whether the item's age field is within the max age, an item without any times counts as recent
+func (module *RSSUpdateModule) isRecent(item *gofeed.Item, now time.Time) bool {
+
+	maxAge := module.rssFeed.GetMaxAge()
 
-	for _, item := range module.difference(module.lastItems, items) {
+	isWithinMaxAge := func(itemTime *time.Time) bool {
+		return itemTime == nil || now.Sub(*itemTime) < maxAge
+	}
 
-		timeSincePublished := time.Second * 1
-		timeSinceUpdated := time.Second * 1
+	isPublishedRecent := isWithinMaxAge(item.PublishedParsed)
+	isUpdatedRecent := isWithinMaxAge(item.UpdatedParsed)
 
-		if item.PublishedParsed != nil {
-			timeSincePublished = time.Since(*item.PublishedParsed)
+	switch module.rssFeed.GetAgeField() {
+	case data.PublishedAge:
+		// RSS items usually only have one of the times, so the other one is used if it's missing
+		if item.PublishedParsed == nil {
+			return isUpdatedRecent
 		}
-
-		if item.UpdatedParsed != nil {
-			timeSinceUpdated = time.Since(*item.UpdatedParsed)
+		return isPublishedRecent
+	case data.UpdatedAge:
+		if item.UpdatedParsed == nil {
+			return isPublishedRecent
 		}
-
-		if timeSincePublished.Hours() < 24 && timeSinceUpdated.Hours() < 24 {
-			itemCopy := item // needed since item changes what it points to
-			updates = append(updates, itemCopy)
+		return isUpdatedRecent
+	case data.EitherAge:
+		// Only one of the times being missing shouldn't make an old item recent
+		if item.PublishedParsed == nil || item.UpdatedParsed == nil {
+			return isPublishedRecent && isUpdatedRecent
 		}
+		return isPublishedRecent || isUpdatedRecent
+	default:
+		return isPublishedRecent && isUpdatedRecent
 	}
-
-	return
 }
 
 func (module *RSSUpdateModule) difference(oldValues []*gofeed.Item, newValues []*gofeed.Item) []*gofeed.Item {
 
 	var result []*gofeed.Item
 
-	for _, newValue := range newValues {
-
-		isOld := false
+	identity := module.rssFeed.GetIdentity()
 
-		for _, oldValue := range oldValues {
-			if oldValue.Title == newValue.Title {
-				isOld = true
-				break
-			}
-		}
+	oldIdentities := make(map[string]bool, len(oldValues))
+	for _, oldValue := range oldValues {
+		oldIdentities[itemIdentity(oldValue, identity)] = true
+	}
 
-		if !isOld {
+	for _, newValue := range newValues {
+		if !oldIdentities[itemIdentity(newValue, identity)] {
 			result = append(result, newValue)
 		}
 	}
@@ -359,105 +391,115 @@ func (module *RSSUpdateModule) difference(oldValues []*gofeed.Item, newValues []
 	return result
 }
 
-func (module *RSSUpdateModule) filePath() string {
+// unseen Returns the items that aren't in the seen set
+func (module *RSSUpdateModule) unseen(items []*gofeed.Item) (result []*gofeed.Item) {
 
-	var fileName string
+	identity := module.rssFeed.GetIdentity()
 
-	if module.rssFeed.FileName != nil {
-		fileName = *module.rssFeed.FileName
-	} else {
-		fileName = utils.SubstringAfter(module.rssFeed.FeedURL, "//")
-		fileName = utils.SubstringAfter(fileName, "www.")
-		fileName = strings.ReplaceAll(fileName, "/", "_")
+	for _, item := range items {
+		if !module.seen.contains(itemIdentity(item, identity)) {
+			result = append(result, item)
+		}
 	}
 
-	return path.Join("Modules", "RSS", fileName+".json")
+	return
 }
 
-func (module *RSSUpdateModule) saveLastItems() {
-	err := utils.WriteJsonAfterMakeDirs(module.filePath(), module.lastItems)
-	if err != nil {
-		log.Fatal(errors.Wrap(err, "failed to saveLastItems"))
+// rememberSeen Adds the items to the seen set and compacts it
+func (module *RSSUpdateModule) rememberSeen(items []*gofeed.Item, now time.Time) {
+
+	identity := module.rssFeed.GetIdentity()
+
+	for _, item := range items {
+		module.seen.add(itemIdentity(item, identity), now)
 	}
+
+	module.seen.compact(now, module.rssFeed.GetSeenTTL(), module.rssFeed.GetSeenLimit())
 }
 
-// pullSavedData Pulls data from the saved file from the last update
-func (module *RSSUpdateModule) pullSavedData() []*gofeed.Item {
+// loadSeenSet Reads the seen set, seeding it from the saved items if it is missing or used another identity strategy
+func (module *RSSUpdateModule) loadSeenSet(lastItems []*gofeed.Item) *seenSet {
 
-	jsonData, err := os.ReadFile(module.filePath())
-	if err != nil {
+	identity := module.rssFeed.GetIdentity()
 
-		if errors.Is(err, os.ErrNotExist) {
-			return []*gofeed.Item{}
-		}
+	set, err := readSeenSet(module.store, module.seenKey())
+	if err != nil {
+		log.Printf("%v", err)
+	}
 
-		log.Fatal(fmt.Errorf("pullSavedData error: %w\n", err))
+	if set != nil && set.Identity == identity {
+		return set
 	}
 
-	var result = new([]*gofeed.Item)
+	// Also how feeds saved before the seen set existed are migrated
+	set = newSeenSet(identity)
 
-	err = jsoniter.Unmarshal(jsonData, result)
-	if err != nil {
-		log.Fatal(fmt.Errorf("pullSavedData error: %w\n", err))
+	now := time.Now()
+	for _, item := range lastItems {
+		set.add(itemIdentity(item, identity), now)
 	}
 
-	return *result
+	return set
 }
 
-func (module *RSSUpdateModule) applyTitle(embed *discordgo.MessageEmbed) {
-	if module.rssFeed.Title != nil {
-		embed.Title = *module.rssFeed.Title
-	}
+// seenKey The seen set is saved next to the state
+func (module *RSSUpdateModule) seenKey() string {
+	return module.stateKey() + ".seen"
 }
 
-func (module *RSSUpdateModule) applyColor(embed *discordgo.MessageEmbed) {
-	if module.rssFeed.Color != nil {
+// stateKey The key of the feed's state, the file name the JSON store has always used for it
+func (module *RSSUpdateModule) stateKey() string {
 
-		color, err := strconv.ParseUint(strings.TrimPrefix(*module.rssFeed.Color, "#"), 16, 32)
-		if err != nil {
-			log.Fatal(fmt.Errorf("applyColor failed: %w", err))
-		}
+	var fileName string
 
-		embed.Color = int(color)
+	if module.rssFeed.FileName != nil {
+		fileName = *module.rssFeed.FileName
+	} else {
+		fileName = utils.SubstringAfter(module.rssFeed.FeedURL, "//")
+		fileName = utils.SubstringAfter(fileName, "www.")
+		fileName = strings.ReplaceAll(fileName, "/", "_")
 	}
-}
 
-func (module *RSSUpdateModule) applyAuthor(item *gofeed.Item, embed *discordgo.MessageEmbed) {
-	if module.rssFeed.Author != nil {
+	return "RSS/" + fileName
+}
 
-		author := *module.rssFeed.Author
-		if len(item.Authors) > 0 {
-			author = strings.ReplaceAll(author, "${entryAuthor}", item.Authors[0].Name)
-		}
+func (module *RSSUpdateModule) saveLastItems() error {
 
-		embed.Author = &discordgo.MessageEmbedAuthor{Name: author}
+	state := rssState{
+		Version:  rssStateVersion,
+		Identity: module.rssFeed.GetIdentity(),
+		Items:    module.lastItems,
 	}
-}
 
-func (module *RSSUpdateModule) applyThumbnail(embed *discordgo.MessageEmbed) {
-	if module.rssFeed.ThumbnailURL != nil {
-		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: *module.rssFeed.ThumbnailURL}
+	err := store.PutJson(module.store, module.stateKey(), state)
+	if err != nil {
+		return errors.Wrap(err, "failed to saveLastItems")
 	}
-}
 
-func (module *RSSUpdateModule) applyDescription(embed *discordgo.MessageEmbed) {
-	if module.rssFeed.Description != nil {
-		embed.Description = *module.rssFeed.Description
-	}
+	return nil
 }
 
-func (module *RSSUpdateModule) embedTemplateForItem(item *gofeed.Item) *discordgo.MessageEmbed {
+// pullSavedData Pulls the state saved by the last update
+func (module *RSSUpdateModule) pullSavedData() ([]*gofeed.Item, error) {
+
+	jsonData, err := module.store.Get(module.stateKey())
+	if err != nil {
+
+		if errors.Is(err, store.ErrNotFound) {
+			return []*gofeed.Item{}, nil
+		}
 
-	embed := &discordgo.MessageEmbed{
-		URL:       item.Link,
-		Title:     item.Title,
-		Timestamp: item.Published,
+		return []*gofeed.Item{}, fmt.Errorf("pullSavedData error: %w", err)
 	}
 
-	module.applyColor(embed)
-	module.applyAuthor(item, embed)
-	module.applyThumbnail(embed)
-	module.applyDescription(embed)
+	state, err := parseRSSState(jsonData)
+	if err != nil {
+		return []*gofeed.Item{}, fmt.Errorf("pullSavedData error: %w", err)
+	}
+
+	if state.Version < rssStateVersion {
+		log.Printf("Migrating %s from state version %d, it is saved as version %d after the next check", module.stateKey(), state.Version, rssStateVersion)
+	}
 
-	return embed
+	return state.Items, nil
 }
@@ -1,28 +1,37 @@
 package module
 
 import (
+	"context"
+	"github.com/bwmarrin/discordgo"
+	"github.com/mmcdole/gofeed"
 	"github.com/stretchr/testify/assert"
-	"path"
+	"net/http"
+	"os"
+	"path/filepath"
 	"privateInfoBot/data"
+	"privateInfoBot/delivery"
+	"privateInfoBot/store"
+	"privateInfoBot/utils"
 	"testing"
+	"time"
 )
 
-func TestRSSUpdateModule_filePath(test *testing.T) {
+func TestRSSUpdateModule_stateKey(test *testing.T) {
 
 	tests := []struct {
-		testName         string
-		url              string
-		expectedFileName string
+		testName    string
+		url         string
+		expectedKey string
 	}{
 		{
-			testName:         "kernelOrg",
-			url:              "https://www.kernel.org/feeds/kdist.xml",
-			expectedFileName: "kernel.org_feeds_kdist.xml.json",
+			testName:    "kernelOrg",
+			url:         "https://www.kernel.org/feeds/kdist.xml",
+			expectedKey: "RSS/kernel.org_feeds_kdist.xml",
 		},
 		{
-			testName:         "redditLongevity",
-			url:              "https://www.reddit.com/r/longevity/.rss",
-			expectedFileName: "reddit.com_r_longevity_.rss.json",
+			testName:    "redditLongevity",
+			url:         "https://www.reddit.com/r/longevity/.rss",
+			expectedKey: "RSS/reddit.com_r_longevity_.rss",
 		},
 	}
 
@@ -33,8 +42,235 @@ func TestRSSUpdateModule_filePath(test *testing.T) {
 				rssFeed: data.RSSFeed{FeedURL: testData.url},
 			}
 
-			expectedFilePath := path.Join("Modules", "RSS", testData.expectedFileName)
-			assert.Equal(test, expectedFilePath, module.filePath())
+			assert.Equal(test, testData.expectedKey, module.stateKey())
+			assert.Equal(test, testData.expectedKey+".seen", module.seenKey())
 		})
 	}
 }
+
+func TestRSSUpdateModule_savedData(test *testing.T) {
+
+	directory := test.TempDir()
+	stateStore := store.NewJSONStore(directory)
+
+	module := NewRSSUpdateModule(time.Minute, data.RSSFeed{FeedURL: "https://example.com/feed"}, data.TransportProfile{}, nil, nil, nil, stateStore)
+
+	items, err := module.pullSavedData()
+	assert.NoError(test, err)
+	assert.Empty(test, items)
+
+	// Version 1 files, saved before the store existed, are read where they always were
+	assert.NoError(test, os.MkdirAll(filepath.Join(directory, "RSS"), 0755))
+	assert.NoError(test, os.WriteFile(filepath.Join(directory, "RSS", "example.com_feed.json"), []byte(`[{"title": "First"}]`), 0644))
+
+	items, err = module.pullSavedData()
+	assert.NoError(test, err)
+	assert.Equal(test, []*gofeed.Item{{Title: "First"}}, items)
+
+	module.lastItems = []*gofeed.Item{{Title: "First"}, {Title: "Second"}}
+	assert.NoError(test, module.saveLastItems())
+
+	items, err = module.pullSavedData()
+	assert.NoError(test, err)
+	assert.Equal(test, module.lastItems, items)
+
+	// A broken state falls back to the one saved before it
+	assert.NoError(test, os.WriteFile(filepath.Join(directory, "RSS", "example.com_feed.json"), []byte(`{"version": 2, "items": [`), 0644))
+
+	items, err = module.pullSavedData()
+	assert.NoError(test, err)
+	assert.Equal(test, []*gofeed.Item{{Title: "First"}}, items)
+
+	// Without a backup it's an error for updateTask to log, not a reason to stop
+	assert.NoError(test, os.Remove(filepath.Join(directory, "RSS", "example.com_feed.json"+utils.BackupExtension)))
+
+	_, err = module.pullSavedData()
+	assert.Error(test, err)
+}
+
+// blockedQueue A queue that fails to save, so posting fails, until unblock is called
+func blockedQueue(test *testing.T) (queue *delivery.Queue, unblock func()) {
+
+	queueDirectory := filepath.Join(test.TempDir(), "queue")
+
+	queue, err := delivery.NewQueue(queueDirectory, nil)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	// A file where the queue's directory goes
+	assert.NoError(test, os.WriteFile(queueDirectory, nil, 0644))
+
+	return queue, func() { assert.NoError(test, os.Remove(queueDirectory)) }
+}
+
+func TestRSSUpdateModule_postNewItems(test *testing.T) {
+
+	queue, unblock := blockedQueue(test)
+
+	feed := data.RSSFeed{ChannelName: "news", FeedURL: "https://example.com/feed"}
+	module := NewRSSUpdateModule(time.Minute, feed, data.TransportProfile{}, map[string]uint64{"news": 1}, queue, nil, nil)
+	module.seen = newSeenSet(feed.GetIdentity())
+
+	oldItem := &gofeed.Item{GUID: "old", Title: "Old", Link: "https://example.com/old"}
+	newItem := &gofeed.Item{GUID: "new", Title: "New", Link: "https://example.com/new"}
+
+	module.rememberSeen([]*gofeed.Item{oldItem}, time.Now())
+
+	// Retried by default, so the failed item isn't remembered
+	assert.False(test, module.postNewItems([]*gofeed.Item{newItem, oldItem}, time.Now()))
+	assert.Empty(test, queue.Pending())
+	assert.Equal(test, []*gofeed.Item{oldItem}, module.lastItems)
+	assert.False(test, module.seen.contains("guid:new"))
+
+	unblock()
+
+	// The next check posts it
+	assert.False(test, module.postNewItems([]*gofeed.Item{newItem, oldItem}, time.Now()))
+	assert.Len(test, queue.Pending(), 1)
+	assert.Equal(test, []*gofeed.Item{newItem, oldItem}, module.lastItems)
+	assert.True(test, module.seen.contains("guid:new"))
+
+	// And only once
+	assert.False(test, module.postNewItems([]*gofeed.Item{newItem, oldItem}, time.Now()))
+	assert.Len(test, queue.Pending(), 1)
+}
+
+// rejectingSender Rejects every message the way Discord does when the channel was deleted
+type rejectingSender struct{}
+
+func (rejectingSender) ChannelMessageSendComplex(string, *discordgo.MessageSend) (*discordgo.Message, error) {
+	return nil, &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusNotFound}}
+}
+
+func (rejectingSender) ChannelMessageCrosspost(string, string) (*discordgo.Message, error) {
+	return nil, &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusNotFound}}
+}
+
+func TestRSSUpdateModule_check_rejected(test *testing.T) {
+
+	queue, err := delivery.NewQueue(test.TempDir(), rejectingSender{})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	disable := data.DisableOnError
+	feed := data.RSSFeed{ChannelName: "news", FeedURL: "https://example.com/feed", ErrorPolicy: &disable}
+
+	module := NewRSSUpdateModule(time.Minute, feed, data.TransportProfile{}, map[string]uint64{"news": 1}, queue, nil, nil)
+	module.seen = newSeenSet(feed.GetIdentity())
+
+	// Queued fine, Discord only rejects it once the queue delivers it
+	assert.False(test, module.postNewItems([]*gofeed.Item{{GUID: "new", Title: "New"}}, time.Now()))
+
+	queue.ProcessDue(context.Background())
+	assert.Len(test, queue.DeadLetters(), 1)
+
+	// Disabled before pulling, so no fetcher is needed
+	shouldDisable, err := module.check(context.Background())
+	assert.NoError(test, err)
+	assert.True(test, shouldDisable)
+
+	assert.NoError(test, queue.TakeRejection(module.stateKey()), "the rejection is only reported once")
+}
+
+func TestRSSUpdateModule_postUpdates_formatError(test *testing.T) {
+
+	badColor := "#nope"
+
+	for _, rssType := range []data.RSSType{data.Github, data.Reddit} {
+
+		feedType := rssType
+
+		module := &RSSUpdateModule{
+			rssFeed: data.RSSFeed{FeedURL: "https://github.com/example/repo/commits/master.atom", Color: &badColor, Type: &feedType},
+		}
+
+		items := []*gofeed.Item{{Title: "First"}, {Title: "Second"}}
+
+		// Formatting fails before anything is sent, so no Discord session is needed
+		failedItems, err := module.postUpdates(items)
+		assert.Error(test, err)
+		assert.Equal(test, items, failedItems)
+
+		// Failed items aren't remembered so they get retried
+		assert.Equal(test, []*gofeed.Item{{Title: "Third"}}, module.difference(failedItems, append(items, &gofeed.Item{Title: "Third"})))
+	}
+}
+
+func TestRSSUpdateModule_filterRecentUpdates(test *testing.T) {
+
+	now := time.Now()
+	hourAgo := now.Add(-time.Hour)
+	weekAgo := now.Add(-time.Hour * 24 * 7)
+
+	fresh := &gofeed.Item{GUID: "fresh", PublishedParsed: &hourAgo, UpdatedParsed: &hourAgo}
+	republished := &gofeed.Item{GUID: "republished", PublishedParsed: &weekAgo, UpdatedParsed: &hourAgo}
+	stale := &gofeed.Item{GUID: "stale", PublishedParsed: &weekAgo}
+	undated := &gofeed.Item{GUID: "undated"}
+
+	items := []*gofeed.Item{fresh, republished, stale, undated}
+
+	eightDays := data.Duration(time.Hour * 24 * 8)
+	updatedAge := data.UpdatedAge
+	eitherAge := data.EitherAge
+	unlimited := data.Unlimited
+
+	tests := []struct {
+		testName       string
+		feed           data.RSSFeed
+		expectedRecent []*gofeed.Item
+		expectedMissed []*gofeed.Item
+	}{
+		{testName: "default", feed: data.RSSFeed{}, expectedRecent: []*gofeed.Item{fresh, undated}},
+		{testName: "maxAge", feed: data.RSSFeed{MaxAge: &eightDays}, expectedRecent: items},
+		{testName: "unlimited", feed: data.RSSFeed{MaxAge: &unlimited}, expectedRecent: items},
+		{testName: "updated", feed: data.RSSFeed{AgeField: &updatedAge}, expectedRecent: []*gofeed.Item{fresh, republished, undated}},
+		{testName: "either", feed: data.RSSFeed{AgeField: &eitherAge}, expectedRecent: []*gofeed.Item{fresh, republished, undated}},
+		{testName: "catchUp", feed: data.RSSFeed{CatchUp: true}, expectedRecent: []*gofeed.Item{fresh, undated}, expectedMissed: []*gofeed.Item{republished, stale}},
+	}
+
+	for _, testData := range tests {
+		test.Run(testData.testName, func(test *testing.T) {
+
+			module := &RSSUpdateModule{rssFeed: testData.feed, seen: newSeenSet(data.GUIDIdentity)}
+
+			recent, missed := module.filterRecentUpdates(items)
+			assert.Equal(test, testData.expectedRecent, recent)
+			assert.Equal(test, testData.expectedMissed, missed)
+		})
+	}
+}
+
+func TestRSSUpdateModule_filterRecentUpdates_versions(test *testing.T) {
+
+	now := time.Now()
+
+	major := &gofeed.Item{GUID: "major", Link: "https://github.com/owner/repo/releases/tag/v6.0.0", UpdatedParsed: &now}
+	patch := &gofeed.Item{GUID: "patch", Link: "https://github.com/owner/repo/releases/tag/v6.0.2", UpdatedParsed: &now}
+	untagged := &gofeed.Item{GUID: "untagged", Title: "Nightly build", UpdatedParsed: &now}
+
+	versions, err := data.VersionFilter("major").Parse()
+	assert.NoError(test, err)
+
+	module := &RSSUpdateModule{rssFeed: data.RSSFeed{}, versions: versions, seen: newSeenSet(data.GUIDIdentity)}
+
+	recent, missed := module.filterRecentUpdates([]*gofeed.Item{major, patch, untagged})
+	assert.Equal(test, []*gofeed.Item{major}, recent)
+	assert.Nil(test, missed)
+}
+
+func TestRSSUpdateModule_filterRecentUpdates_kernel(test *testing.T) {
+
+	now := time.Now()
+
+	longterm := &gofeed.Item{GUID: "5.15.71", Title: "5.15.71: longterm", PublishedParsed: &now}
+	otherLongterm := &gofeed.Item{GUID: "5.10.146", Title: "5.10.146: longterm", PublishedParsed: &now}
+	stable := &gofeed.Item{GUID: "6.0.2", Title: "6.0.2: stable", PublishedParsed: &now}
+
+	feed := data.RSSFeed{Kernel: &data.KernelFilter{Monikers: []data.KernelMoniker{data.Longterm}, Branches: []string{"5.15"}}}
+	module := &RSSUpdateModule{rssFeed: feed, seen: newSeenSet(data.GUIDIdentity)}
+
+	recent, _ := module.filterRecentUpdates([]*gofeed.Item{longterm, otherLongterm, stable})
+	assert.Equal(test, []*gofeed.Item{longterm}, recent)
+}
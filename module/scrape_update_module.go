@@ -0,0 +1,506 @@
+package module
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"io"
+	"net/url"
+	"path"
+	"privateInfoBot/data"
+	"privateInfoBot/delivery"
+	"privateInfoBot/fetch"
+	"privateInfoBot/format"
+	"privateInfoBot/store"
+	"privateInfoBot/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScrapedEntry An entry found on a scraped page, ex: a changelog's updates on one date
+type ScrapedEntry struct {
+	Date  *time.Time `json:"date,omitempty"`
+	Title string     `json:"title,omitempty"`
+	Link  string     `json:"link,omitempty"`
+	Lines []string   `json:"lines,omitempty"`
+}
+
+// key Tells entries apart, an entry that changed in any way is a new one
+func (entry *ScrapedEntry) key() string {
+
+	date := ""
+	if entry.Date != nil {
+		date = entry.Date.UTC().Format(time.RFC3339)
+	}
+
+	return strings.Join(append([]string{date, entry.Title, entry.Link}, entry.Lines...), "\x00")
+}
+
+// legacyStatePaths Where sources that had a module of their own before the scrape module saved their entries,
+// read when the store has nothing for the source yet, the next save moves them into the store
+var legacyStatePaths = map[string]string{
+	"longevityRoadmap": path.Join("Modules", "LongevityIORoadmap", "longevity_io_roadmap.json"),
+}
+
+// legacyRoadmapEntry An entry saved by the longevity roadmap module, the dates and lines of its changelog
+type legacyRoadmapEntry struct {
+	Date    time.Time `json:"date"`
+	Updates []string  `json:"updates"`
+}
+
+// ScrapeUpdateModule Posts the new entries of a page without a feed, found with the CSS selectors of its data.ScrapeSource
+type ScrapeUpdateModule struct {
+	supervisor supervisor
+	checkDelay time.Duration
+	source     data.ScrapeSource
+	channelID  uint64
+	lastItems  []*ScrapedEntry
+	queue      *delivery.Queue
+	fetcher    *fetch.Fetcher
+	store      store.Store
+	// skipPosting Set when nothing was saved yet, so the first check doesn't post the whole page
+	skipPosting bool
+	// validators and pulledItems are from the last full download, reused when the page replies 304
+	validators  fetch.Validators
+	pulledItems []*ScrapedEntry
+}
+
+func NewScrapeUpdateModule(
+	checkDelay time.Duration,
+	source data.ScrapeSource,
+	channels map[string]uint64,
+	queue *delivery.Queue,
+	fetcher *fetch.Fetcher,
+	stateStore store.Store,
+) *ScrapeUpdateModule {
+	return &ScrapeUpdateModule{
+		supervisor: newSupervisor(source.Name),
+		checkDelay: checkDelay,
+		source:     source,
+		channelID:  channels[source.ChannelName],
+		queue:      queue,
+		fetcher:    fetcher,
+		store:      stateStore,
+	}
+}
+
+func (module *ScrapeUpdateModule) IsEnabled() bool {
+	return module.supervisor.isRunning()
+}
+
+func (module *ScrapeUpdateModule) Enable() {
+	module.supervisor.start(module.updateTask)
+}
+
+func (module *ScrapeUpdateModule) Disable() {
+	module.supervisor.stop()
+}
+
+func (module *ScrapeUpdateModule) bind(parent context.Context) {
+	module.supervisor.bind(parent)
+}
+
+// pullSavedData Pulls the entries saved by the last update, or by the source's old module if there aren't any
+func (module *ScrapeUpdateModule) pullSavedData() ([]*ScrapedEntry, error) {
+
+	var result []*ScrapedEntry
+
+	found, err := store.GetJson(module.store, module.stateKey(), &result)
+	if err != nil {
+		return []*ScrapedEntry{}, errors.Wrap(err, "pullSavedData error")
+	}
+
+	if !found {
+		return module.pullLegacyData()
+	}
+
+	return result, nil
+}
+
+// pullLegacyData Converts the entries saved by the source's old module, nil if it didn't have one.
+// Lines are collapsed the way parseScrapedEntry collapses them, so the same entries aren't posted again
+func (module *ScrapeUpdateModule) pullLegacyData() ([]*ScrapedEntry, error) {
+
+	legacyPath, ok := legacyStatePaths[module.source.Name]
+	if !ok {
+		return nil, nil
+	}
+
+	var legacyEntries []*legacyRoadmapEntry
+
+	_, err := utils.ReadJson(legacyPath, &legacyEntries)
+	if err != nil {
+		return []*ScrapedEntry{}, errors.Wrap(err, "pullLegacyData error")
+	}
+
+	var result []*ScrapedEntry
+
+	for _, legacyEntry := range legacyEntries {
+
+		date := legacyEntry.Date
+		entry := &ScrapedEntry{Date: &date}
+
+		for _, update := range legacyEntry.Updates {
+			if line := collapseSpaces(update); line != "" {
+				entry.Lines = append(entry.Lines, line)
+			}
+		}
+
+		result = append(result, entry)
+	}
+
+	return result, nil
+}
+
+func (module *ScrapeUpdateModule) updateTask(ctx context.Context) {
+	runUpdateLoop(ctx, module.source.Name, module.checkDelay, module.loadState, module.check)
+}
+
+func (module *ScrapeUpdateModule) loadState() error {
+
+	lastItems, err := module.pullSavedData()
+
+	module.lastItems = lastItems
+	module.skipPosting = len(lastItems) == 0
+
+	return err
+}
+
+// check Pulls the page, posts the entries that weren't there last time and saves the ones that posted
+func (module *ScrapeUpdateModule) check(ctx context.Context) (shouldDisable bool, err error) {
+
+	if onRejected(module.queue, module.stateKey(), module.source.Name, module.source.GetErrorPolicy()) {
+		return true, nil
+	}
+
+	pulledItems, err := module.pullItems(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if module.skipPosting {
+		module.skipPosting = false
+		module.lastItems = pulledItems
+	} else {
+		shouldDisable = module.postNewItems(pulledItems)
+	}
+
+	err = module.saveLastItems()
+	if err != nil {
+		shouldDisable = onSaveError(module.source.GetErrorPolicy(), err) || shouldDisable
+	}
+
+	return shouldDisable, nil
+}
+
+// postNewItems Posts the pulled items that weren't there last time and remembers the ones that posted, returning whether the error policy disables the module
+func (module *ScrapeUpdateModule) postNewItems(pulledItems []*ScrapedEntry) (shouldDisable bool) {
+
+	var failedItems []*ScrapedEntry
+	var err error
+
+	recentUpdates := module.difference(module.lastItems, pulledItems)
+	if len(recentUpdates) != 0 {
+		failedItems, err = module.postUpdates(recentUpdates)
+	}
+
+	if err != nil {
+
+		var skip bool
+		skip, shouldDisable = onPostError(module.source.Name, module.source.GetErrorPolicy(), err)
+
+		if skip {
+			failedItems = nil
+		}
+	}
+
+	// Failed items aren't remembered, so they are seen as new on the next check
+	module.lastItems = module.difference(failedItems, pulledItems)
+
+	return shouldDisable
+}
+
+// postUpdates Queues the items, returning the ones that failed to queue
+func (module *ScrapeUpdateModule) postUpdates(items []*ScrapedEntry) (failedItems []*ScrapedEntry, err error) {
+
+	channelIDString := strconv.FormatUint(module.channelID, 10)
+
+	err = module.queue.Enqueue(module.stateKey(), channelIDString, format.Limit(module.itemsToMessages(items)), true)
+	if err != nil {
+		return items, fmt.Errorf("postUpdates failed channel (%s:%s): %w", module.source.ChannelName, channelIDString, err)
+	}
+
+	return nil, nil
+}
+
+func (module *ScrapeUpdateModule) itemsToMessages(items []*ScrapedEntry) (messages []discordgo.MessageSend) {
+
+	color := format.EmbedColor(module.source.Color)
+
+	for _, item := range items {
+
+		embed := &discordgo.MessageEmbed{
+			URL:   module.source.URL,
+			Title: item.Title,
+			Color: color,
+		}
+
+		if item.Link != "" {
+			embed.URL = item.Link
+		}
+
+		if item.Date != nil {
+
+			embed.Timestamp = format.Timestamp(*item.Date)
+
+			if embed.Title == "" {
+				embed.Title = fmt.Sprintf("Update found to %v!", item.Date.Format("2 January 2006"))
+			}
+		}
+
+		if embed.Title == "" {
+			embed.Title = "Update found!"
+		}
+
+		if module.source.Description != nil {
+			embed.Description = *module.source.Description
+		}
+
+		if module.source.ThumbnailURL != nil {
+			embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: *module.source.ThumbnailURL}
+		}
+
+		if len(item.Lines) > 0 {
+
+			lines := make([]string, 0, len(item.Lines))
+			for _, line := range item.Lines {
+				lines = append(lines, ":green_circle: "+line)
+			}
+
+			embed.Fields = format.ListFields("Changelog", lines, "\n\n", module.source.URL)
+		}
+
+		messages = append(messages, discordgo.MessageSend{Embed: embed})
+	}
+
+	return
+}
+
+func (module *ScrapeUpdateModule) difference(oldValues []*ScrapedEntry, newValues []*ScrapedEntry) []*ScrapedEntry {
+
+	var result []*ScrapedEntry
+
+	oldKeys := make(map[string]bool, len(oldValues))
+	for _, oldValue := range oldValues {
+		oldKeys[oldValue.key()] = true
+	}
+
+	for _, newValue := range newValues {
+		if !oldKeys[newValue.key()] {
+			result = append(result, newValue)
+		}
+	}
+
+	return result
+}
+
+// pullItems Downloads the page and parses its entries
+func (module *ScrapeUpdateModule) pullItems(ctx context.Context) ([]*ScrapedEntry, error) {
+
+	fmt.Printf("(%v) Pulling: %v\n", time.Now().Format("02 Jan 2006 03:04PM MST"), module.source.URL)
+
+	// Without the items from last time a 304 would leave nothing to work with
+	if module.pulledItems == nil {
+		module.validators = fetch.Validators{}
+	}
+
+	response, err := module.fetcher.Fetch(ctx, module.source.URL, &module.validators, fetch.Options{})
+	if err != nil {
+		return nil, errors.Wrap(err, "pullItems error")
+	}
+
+	if response.NotModified {
+		return module.pulledItems, nil
+	}
+
+	entries, err := parseScrapedEntries(module.source, bytes.NewReader(response.Body))
+	if err != nil {
+		return nil, errors.Wrap(err, "pullItems error")
+	}
+
+	module.pulledItems = entries
+
+	return entries, nil
+}
+
+// parseScrapedEntries Walks the container's children, see data.ScrapeSelectors
+func parseScrapedEntries(source data.ScrapeSource, reader io.Reader) ([]*ScrapedEntry, error) {
+
+	selectors := source.Selectors
+
+	document, err := goquery.NewDocumentFromReader(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get document reader")
+	}
+
+	pageURL, err := url.Parse(source.URL)
+	if err != nil {
+		return nil, errors.Wrap(err, "parseScrapedEntries failed")
+	}
+
+	container := document.Find(selectors.Container).First()
+	if container.Length() == 0 {
+		return nil, errors.Errorf("container not found: %s", selectors.Container)
+	}
+
+	var heading *goquery.Selection
+	var entries []*ScrapedEntry
+
+	container.Children().EachWithBreak(func(i int, child *goquery.Selection) bool {
+
+		switch {
+
+		case selectors.Skip != "" && child.Is(selectors.Skip):
+			return true
+
+		case selectors.Heading != "" && child.Is(selectors.Heading):
+			heading = child
+
+		case child.Is(selectors.Entry):
+
+			if selectors.Heading != "" && heading == nil {
+				err = errors.Errorf("entry before any heading: %s", strings.TrimSpace(child.Text()))
+				return false
+			}
+
+			var entry *ScrapedEntry
+
+			// Needs to be defined this way to not redefine `err`
+			entry, err = parseScrapedEntry(source, pageURL, child, heading)
+			if err != nil {
+				return false
+			}
+
+			entries = append(entries, entry)
+
+		case source.Strict:
+			err = errors.Errorf("unexpected tag data: %s", goquery.NodeName(child))
+		}
+
+		return err == nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func parseScrapedEntry(source data.ScrapeSource, pageURL *url.URL, element *goquery.Selection, heading *goquery.Selection) (*ScrapedEntry, error) {
+
+	selectors := source.Selectors
+
+	// find Looks in the entry, then in its heading
+	find := func(selector string) *goquery.Selection {
+
+		found := element.Find(selector).First()
+		if found.Length() == 0 && heading != nil {
+			found = heading.Find(selector).First()
+		}
+
+		return found
+	}
+
+	entry := &ScrapedEntry{}
+
+	if selectors.Title != "" {
+		entry.Title = collapseSpaces(find(selectors.Title).Text())
+	}
+
+	if selectors.Link != "" {
+		if href, ok := find(selectors.Link).Attr("href"); ok {
+			if link, err := pageURL.Parse(href); err == nil {
+				entry.Link = link.String()
+			}
+		}
+	}
+
+	dateText := ""
+	switch {
+	case selectors.Date != "":
+		dateElement := find(selectors.Date)
+		// <time> elements have a machine readable date
+		if datetime, ok := dateElement.Attr("datetime"); ok {
+			dateText = datetime
+		} else {
+			dateText = collapseSpaces(dateElement.Text())
+		}
+	case heading != nil:
+		dateText = collapseSpaces(heading.Text())
+	}
+
+	if dateText != "" {
+
+		date, err := parseScrapedDate(dateText, source.GetDateFormats())
+		if err != nil {
+			return nil, err
+		}
+
+		entry.Date = date
+	}
+
+	if selectors.Lines != "" {
+		element.Find(selectors.Lines).Each(func(i int, line *goquery.Selection) {
+			if text := collapseSpaces(line.Text()); text != "" {
+				entry.Lines = append(entry.Lines, text)
+			}
+		})
+	} else {
+		for _, line := range strings.Split(element.Text(), "\n") {
+			if text := collapseSpaces(line); text != "" {
+				entry.Lines = append(entry.Lines, text)
+			}
+		}
+	}
+
+	return entry, nil
+}
+
+// parseScrapedDate Tries the formats in order, then RFC 3339 for <time> elements
+func parseScrapedDate(text string, formats []string) (*time.Time, error) {
+
+	for _, layout := range formats {
+		if date, err := time.Parse(layout, text); err == nil {
+			return &date, nil
+		}
+	}
+
+	if date, err := time.Parse(time.RFC3339, text); err == nil {
+		return &date, nil
+	}
+
+	return nil, errors.Errorf("failed to parse date: %s", text)
+}
+
+func collapseSpaces(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func (module *ScrapeUpdateModule) saveLastItems() error {
+
+	err := store.PutJson(module.store, module.stateKey(), module.lastItems)
+	if err != nil {
+		return errors.Wrap(err, "failed to saveLastItems")
+	}
+
+	return nil
+}
+
+func (module *ScrapeUpdateModule) stateKey() string {
+	return "Scrape/" + module.source.Name
+}
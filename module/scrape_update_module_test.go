@@ -0,0 +1,243 @@
+package module
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"privateInfoBot/data"
+	"privateInfoBot/delivery"
+	"privateInfoBot/fetch"
+	"privateInfoBot/store"
+	"testing"
+	"time"
+)
+
+func scrapeDate(year int, month time.Month, day int) *time.Time {
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return &date
+}
+
+// longevitySource The longevity roadmap entry of the scrape sources file, so the tests cover the real config
+func longevitySource(test *testing.T) data.ScrapeSource {
+
+	config, err := data.ReadScrapeSources(filepath.Join("..", "scrapeSources.json"))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	for _, source := range config.Sources {
+		if source.Name == "longevityRoadmap" {
+			return source
+		}
+	}
+
+	test.Fatal("longevityRoadmap isn't in scrapeSources.json")
+
+	return data.ScrapeSource{}
+}
+
+func TestParseScrapedEntries(test *testing.T) {
+
+	releasesDate := time.Date(2022, time.October, 14, 9, 30, 0, 0, time.UTC)
+
+	releasesSource := data.ScrapeSource{
+		Name: "releases",
+		URL:  "https://example.com/releases",
+		Selectors: data.ScrapeSelectors{
+			Container: "main.releases",
+			Entry:     "article.release",
+			Title:     "h2",
+			Link:      "h2 a",
+			Date:      "time",
+			Lines:     "li",
+		},
+		DateFormats: []string{"January 2, 2006"},
+	}
+
+	withoutLines := releasesSource
+	withoutLines.Selectors.Lines = ""
+
+	strictReleases := releasesSource
+	strictReleases.Strict = true
+
+	tests := []struct {
+		testName    string
+		page        string
+		source      data.ScrapeSource
+		expected    []*ScrapedEntry
+		expectError bool
+	}{
+		{
+			testName: "longevityBasic",
+			page:     "longevity_basic",
+			source:   longevitySource(test),
+			expected: []*ScrapedEntry{
+				{Date: scrapeDate(2022, time.October, 12), Lines: []string{"Added Senolytic X to the senescent cells hallmark", "Moved Rapamycin to phase 2 trials"}},
+				{Date: scrapeDate(2022, time.September, 3), Lines: []string{"Updated the NAD+ precursors"}},
+			},
+		},
+		{
+			testName: "longevityWithSection",
+			page:     "longevity_with_section",
+			source:   longevitySource(test),
+			expected: []*ScrapedEntry{
+				{Date: scrapeDate(2022, time.October, 12), Lines: []string{"Moved Rapamycin to phase 2 trials"}},
+				{Date: scrapeDate(2019, time.January, 1), Lines: []string{"Started the roadmap"}},
+			},
+		},
+		{testName: "longevityUnexpectedTag", page: "longevity_unexpected_tag", source: longevitySource(test), expectError: true},
+		{testName: "longevityListBeforeDate", page: "longevity_list_before_date", source: longevitySource(test), expectError: true},
+		{testName: "longevityBadDate", page: "longevity_bad_date", source: longevitySource(test), expectError: true},
+		{
+			testName: "releases",
+			page:     "releases",
+			source:   releasesSource,
+			expected: []*ScrapedEntry{
+				{Date: &releasesDate, Title: "Version 2.1", Link: "https://example.com/releases/2.1", Lines: []string{"Faster startup", "Dark mode"}},
+				{Date: scrapeDate(2022, time.September, 1), Title: "Version 2.0", Link: "https://example.com/releases/2.0"},
+			},
+		},
+		{
+			testName: "releasesWithoutLines",
+			page:     "releases",
+			source:   withoutLines,
+			expected: []*ScrapedEntry{
+				{Date: &releasesDate, Title: "Version 2.1", Link: "https://example.com/releases/2.1", Lines: []string{"Version 2.1", "October 14, 2022", "Faster startup", "Dark mode"}},
+				{Date: scrapeDate(2022, time.September, 1), Title: "Version 2.0", Link: "https://example.com/releases/2.0", Lines: []string{"Version 2.0", "September 1, 2022", "First release", "of the new app."}},
+			},
+		},
+		{testName: "releasesStrict", page: "releases", source: strictReleases, expectError: true},
+	}
+
+	for _, testData := range tests {
+		test.Run(testData.testName, func(test *testing.T) {
+
+			page, err := os.Open(filepath.Join("testdata", "scrape", testData.page+".html"))
+			if err != nil {
+				test.Fatal(err)
+			}
+
+			defer page.Close()
+
+			entries, err := parseScrapedEntries(testData.source, page)
+			if testData.expectError {
+				assert.Error(test, err)
+				return
+			}
+
+			assert.NoError(test, err)
+			assert.Equal(test, testData.expected, entries)
+		})
+	}
+}
+
+func TestScrapeUpdateModule_pullItems(test *testing.T) {
+
+	page, err := os.ReadFile(filepath.Join("testdata", "scrape", "longevity_basic.html"))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path != "/roadmap" {
+			http.NotFound(writer, request)
+			return
+		}
+		_, _ = writer.Write(page)
+	}))
+
+	defer server.Close()
+
+	fetcher := fetch.NewFetcher()
+	fetcher.MinInterval = 0
+
+	source := longevitySource(test)
+	source.URL = server.URL + "/roadmap"
+
+	module := NewScrapeUpdateModule(time.Minute, source, nil, nil, fetcher, nil)
+
+	entries, err := module.pullItems(context.Background())
+	assert.NoError(test, err)
+	assert.Len(test, entries, 2)
+
+	module.source.URL = server.URL + "/missing"
+	_, err = module.pullItems(context.Background())
+	assert.Error(test, err)
+}
+
+func TestScrapeUpdateModule_pullSavedData_legacy(test *testing.T) {
+
+	legacyPath := filepath.Join(test.TempDir(), "longevity_io_roadmap.json")
+
+	oldPath := legacyStatePaths["longevityRoadmap"]
+	legacyStatePaths["longevityRoadmap"] = legacyPath
+	test.Cleanup(func() { legacyStatePaths["longevityRoadmap"] = oldPath })
+
+	// Saved by the longevity roadmap module, its lines were split on the page source's line breaks
+	legacyState := `[
+		{"date": "2022-10-12T00:00:00Z", "updates": ["Added Senolytic X to the senescent cells hallmark", "  Moved Rapamycin to phase 2 trials"]},
+		{"date": "2022-09-03T00:00:00Z", "updates": ["Updated the NAD+ precursors", ""]}
+	]`
+	assert.NoError(test, os.WriteFile(legacyPath, []byte(legacyState), 0644))
+
+	module := NewScrapeUpdateModule(time.Minute, longevitySource(test), nil, nil, nil, store.NewJSONStore(test.TempDir()))
+
+	items, err := module.pullSavedData()
+	assert.NoError(test, err)
+
+	page, err := os.Open(filepath.Join("testdata", "scrape", "longevity_basic.html"))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	defer page.Close()
+
+	pulledItems, err := parseScrapedEntries(module.source, page)
+	assert.NoError(test, err)
+	assert.Empty(test, module.difference(items, pulledItems), "entries the old module posted aren't posted again")
+
+	// Once saved, the store is read instead
+	module.lastItems = pulledItems[:1]
+	assert.NoError(test, module.saveLastItems())
+
+	items, err = module.pullSavedData()
+	assert.NoError(test, err)
+	assert.Equal(test, pulledItems[:1], items)
+
+	// Other sources never had a module of their own
+	other := NewScrapeUpdateModule(time.Minute, data.ScrapeSource{Name: "releases"}, nil, nil, nil, store.NewJSONStore(test.TempDir()))
+
+	items, err = other.pullSavedData()
+	assert.NoError(test, err)
+	assert.Empty(test, items)
+}
+
+func TestScrapeUpdateModule_postNewItems(test *testing.T) {
+
+	queue, err := delivery.NewQueue(test.TempDir(), nil)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	oldEntry := &ScrapedEntry{Date: scrapeDate(2022, time.September, 3), Lines: []string{"Updated the NAD+ precursors"}}
+	newEntry := &ScrapedEntry{Date: scrapeDate(2022, time.October, 12), Lines: []string{"Moved Rapamycin to phase 2 trials"}}
+
+	module := NewScrapeUpdateModule(time.Minute, longevitySource(test), map[string]uint64{"longevityNews": 1}, queue, nil, nil)
+	module.lastItems = []*ScrapedEntry{oldEntry}
+
+	// Nothing changed, so nothing is posted
+	assert.False(test, module.postNewItems([]*ScrapedEntry{oldEntry}))
+	assert.Empty(test, queue.Pending())
+
+	assert.False(test, module.postNewItems([]*ScrapedEntry{newEntry, oldEntry}))
+	assert.Len(test, queue.Pending(), 1)
+	assert.Equal(test, []*ScrapedEntry{newEntry, oldEntry}, module.lastItems)
+
+	pending := queue.Pending()[0]
+	assert.Equal(test, "1", pending.ChannelID)
+	assert.Equal(test, "Update found to 12 October 2022!", pending.Message.Embeds[0].Title)
+	assert.Equal(test, ":green_circle: Moved Rapamycin to phase 2 trials", pending.Message.Embeds[0].Fields[0].Value)
+}
@@ -0,0 +1,95 @@
+package module
+
+import (
+	"fmt"
+	"privateInfoBot/data"
+	"privateInfoBot/store"
+	"sort"
+	"time"
+)
+
+// seenSet The identities of items that were already handled, with when they were last in the feed
+type seenSet struct {
+	Identity data.IdentityStrategy `json:"identity"`
+	Seen     map[string]time.Time  `json:"seen"`
+}
+
+func newSeenSet(identity data.IdentityStrategy) *seenSet {
+	return &seenSet{
+		Identity: identity,
+		Seen:     map[string]time.Time{},
+	}
+}
+
+// readSeenSet Reads the seen set, returning nil if it wasn't saved yet
+func readSeenSet(stateStore store.Store, key string) (*seenSet, error) {
+
+	set := new(seenSet)
+
+	found, err := store.GetJson(stateStore, key, set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seen set: %w", err)
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	if set.Seen == nil {
+		set.Seen = map[string]time.Time{}
+	}
+
+	return set, nil
+}
+
+func (set *seenSet) save(stateStore store.Store, key string) error {
+
+	err := store.PutJson(stateStore, key, set)
+	if err != nil {
+		return fmt.Errorf("failed to save seen set: %w", err)
+	}
+
+	return nil
+}
+
+func (set *seenSet) contains(identity string) bool {
+	_, ok := set.Seen[identity]
+	return ok
+}
+
+// add Adds the identity or refreshes when it was last seen
+func (set *seenSet) add(identity string, now time.Time) {
+	set.Seen[identity] = now
+}
+
+// compact Forgets identities not seen within the ttl, then the oldest ones until there are at most limit left
+func (set *seenSet) compact(now time.Time, ttl time.Duration, limit int) {
+
+	for identity, lastSeen := range set.Seen {
+		if now.Sub(lastSeen) > ttl {
+			delete(set.Seen, identity)
+		}
+	}
+
+	// Validated when the config is read, but a negative limit would slice out of range
+	if limit < 0 {
+		limit = 0
+	}
+
+	if len(set.Seen) <= limit {
+		return
+	}
+
+	identities := make([]string, 0, len(set.Seen))
+	for identity := range set.Seen {
+		identities = append(identities, identity)
+	}
+
+	sort.Slice(identities, func(i, j int) bool {
+		return set.Seen[identities[i]].Before(set.Seen[identities[j]])
+	})
+
+	for _, identity := range identities[:len(identities)-limit] {
+		delete(set.Seen, identity)
+	}
+}
@@ -0,0 +1,67 @@
+package module
+
+import (
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"privateInfoBot/data"
+	"privateInfoBot/store"
+	"testing"
+	"time"
+)
+
+func TestSeenSet_compact(test *testing.T) {
+
+	now := time.Now()
+
+	set := newSeenSet(data.GUIDIdentity)
+	set.add("guid:expired", now.Add(-time.Hour*3))
+	set.add("guid:oldest", now.Add(-time.Hour*2+time.Minute))
+	set.add("guid:older", now.Add(-time.Hour))
+	set.add("guid:newest", now)
+
+	set.compact(now, time.Hour*2, 2)
+
+	assert.False(test, set.contains("guid:expired"), "entries past the ttl should be forgotten")
+	assert.False(test, set.contains("guid:oldest"), "the oldest entries beyond the limit should be forgotten")
+	assert.True(test, set.contains("guid:older"))
+	assert.True(test, set.contains("guid:newest"))
+}
+
+func TestSeenSet_compactNegativeLimit(test *testing.T) {
+
+	now := time.Now()
+
+	set := newSeenSet(data.GUIDIdentity)
+	set.add("guid:1", now)
+
+	assert.NotPanics(test, func() { set.compact(now, time.Hour, -1) })
+	assert.Empty(test, set.Seen)
+}
+
+func TestSeenSet_saveAndRead(test *testing.T) {
+
+	stateStore := store.NewJSONStore(test.TempDir())
+
+	missing, err := readSeenSet(stateStore, "RSS/Feed.seen")
+	assert.NoError(test, err)
+	assert.Nil(test, missing)
+
+	set := newSeenSet(data.LinkIdentity)
+	set.add("link:https://example.com/a", time.Now())
+	assert.NoError(test, set.save(stateStore, "RSS/Feed.seen"))
+
+	read, err := readSeenSet(stateStore, "RSS/Feed.seen")
+	assert.NoError(test, err)
+	assert.Equal(test, data.LinkIdentity, read.Identity)
+	assert.True(test, read.contains("link:https://example.com/a"))
+}
+
+func TestRSSUpdateModule_unseen(test *testing.T) {
+
+	module := &RSSUpdateModule{seen: newSeenSet(data.GUIDIdentity)}
+	module.seen.add("guid:1", time.Now())
+
+	items := []*gofeed.Item{{GUID: "1"}, {GUID: "2"}}
+
+	assert.Equal(test, items[1:], module.unseen(items))
+}
@@ -0,0 +1,178 @@
+package module
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+const (
+	minRestartBackoff = time.Second * 5
+	maxRestartBackoff = time.Minute * 10
+)
+
+// supervisor Runs a module's update loop in a goroutine with a context that is cancelled on stop,
+// restarting the loop with backoff if it panics
+type supervisor struct {
+	mutex     sync.Mutex
+	name      string
+	isEnabled bool
+	parent    context.Context
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+func newSupervisor(name string) supervisor {
+	return supervisor{name: name}
+}
+
+// bind Sets the context the loop's context derives from, so cancelling it stops the loop
+func (supervisor *supervisor) bind(parent context.Context) {
+	supervisor.mutex.Lock()
+	defer supervisor.mutex.Unlock()
+	supervisor.parent = parent
+}
+
+func (supervisor *supervisor) context() context.Context {
+
+	supervisor.mutex.Lock()
+	defer supervisor.mutex.Unlock()
+
+	if supervisor.parent == nil {
+		return context.Background()
+	}
+
+	return supervisor.parent
+}
+
+func (supervisor *supervisor) isRunning() bool {
+	supervisor.mutex.Lock()
+	defer supervisor.mutex.Unlock()
+	return supervisor.isEnabled
+}
+
+// start Starts the loop if it isn't already running
+func (supervisor *supervisor) start(run func(ctx context.Context)) {
+
+	supervisor.mutex.Lock()
+	defer supervisor.mutex.Unlock()
+
+	if supervisor.isEnabled {
+		return
+	}
+
+	parent := supervisor.parent
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	done := make(chan struct{})
+
+	supervisor.isEnabled = true
+	supervisor.cancel = cancel
+	supervisor.done = done
+
+	go supervisor.supervise(ctx, done, run)
+}
+
+// stop Cancels the loop and waits for it to finish what it is doing
+func (supervisor *supervisor) stop() {
+
+	supervisor.mutex.Lock()
+
+	if !supervisor.isEnabled {
+		supervisor.mutex.Unlock()
+		return
+	}
+
+	supervisor.isEnabled = false
+	supervisor.cancel()
+	done := supervisor.done
+
+	supervisor.mutex.Unlock()
+
+	<-done
+}
+
+func (supervisor *supervisor) supervise(ctx context.Context, done chan struct{}, run func(ctx context.Context)) {
+
+	defer close(done)
+
+	backoff := minRestartBackoff
+
+	for {
+
+		startTime := time.Now()
+
+		panicked := supervisor.runRecovering(ctx, run)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// The loop stopped itself, ex: due to an error policy
+		if !panicked {
+			supervisor.stopped(done)
+			return
+		}
+
+		// Ran fine for a while before panicking, so start backing off from the beginning again
+		if time.Since(startTime) > maxRestartBackoff {
+			backoff = minRestartBackoff
+		}
+
+		log.Printf("Restarting %s in %v", supervisor.name, backoff)
+
+		if !sleepContext(ctx, backoff) {
+			return
+		}
+
+		backoff *= 2
+		if backoff > maxRestartBackoff {
+			backoff = maxRestartBackoff
+		}
+	}
+}
+
+// stopped Marks the supervisor as disabled if it is still running the loop that finished
+func (supervisor *supervisor) stopped(done chan struct{}) {
+
+	supervisor.mutex.Lock()
+	defer supervisor.mutex.Unlock()
+
+	if supervisor.done == done {
+		supervisor.isEnabled = false
+		supervisor.cancel()
+	}
+}
+
+// runRecovering Runs the loop, returning true if it panicked
+func (supervisor *supervisor) runRecovering(ctx context.Context, run func(ctx context.Context)) (panicked bool) {
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Printf("%s panicked: %v\n%s", supervisor.name, recovered, debug.Stack())
+			panicked = true
+		}
+	}()
+
+	run(ctx)
+
+	return false
+}
+
+// sleepContext Sleeps for the duration, returning false if the context was cancelled first
+func sleepContext(ctx context.Context, duration time.Duration) bool {
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,85 @@
+package module
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testLoopModule struct {
+	supervisor supervisor
+	finished   int32
+}
+
+func (module *testLoopModule) IsEnabled() bool {
+	return module.supervisor.isRunning()
+}
+
+func (module *testLoopModule) Enable() {
+	module.supervisor.start(func(ctx context.Context) {
+		<-ctx.Done()
+		// Simulates a save that has to finish after cancelling
+		time.Sleep(time.Millisecond * 50)
+		atomic.StoreInt32(&module.finished, 1)
+	})
+}
+
+func (module *testLoopModule) Disable() {
+	module.supervisor.stop()
+}
+
+func (module *testLoopModule) bind(parent context.Context) {
+	module.supervisor.bind(parent)
+}
+
+func TestSupervisor_stopWaitsForLoop(test *testing.T) {
+
+	module := &testLoopModule{supervisor: newSupervisor("test")}
+
+	module.Enable()
+	assert.True(test, module.IsEnabled())
+
+	module.Disable()
+	assert.False(test, module.IsEnabled())
+	assert.Equal(test, int32(1), atomic.LoadInt32(&module.finished))
+
+	// Can be enabled again after being disabled
+	module.Enable()
+	assert.True(test, module.IsEnabled())
+	module.Disable()
+}
+
+func TestSupervisor_runRecovering(test *testing.T) {
+
+	supervisor := newSupervisor("test")
+
+	panicked := supervisor.runRecovering(context.Background(), func(ctx context.Context) {
+		panic("oh no")
+	})
+	assert.True(test, panicked)
+
+	panicked = supervisor.runRecovering(context.Background(), func(ctx context.Context) {})
+	assert.False(test, panicked)
+}
+
+func TestManager_Shutdown(test *testing.T) {
+
+	manager := NewManager(context.Background())
+
+	modules := []*testLoopModule{
+		{supervisor: newSupervisor("first")},
+		{supervisor: newSupervisor("second")},
+	}
+
+	manager.Add(modules[0], modules[1], NewCalculatorModule())
+	manager.EnableAll()
+
+	assert.NoError(test, manager.Shutdown(time.Second))
+
+	for _, module := range modules {
+		assert.False(test, module.IsEnabled())
+		assert.Equal(test, int32(1), atomic.LoadInt32(&module.finished))
+	}
+}
@@ -0,0 +1,84 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"privateInfoBot/data"
+	"privateInfoBot/delivery"
+	"time"
+)
+
+// runUpdateLoop Runs the checks of an RSS, scrape or page module every checkDelay, until the context is done or its error policy disables it.
+// A state that fails to load is logged and the module starts over, which is better than not running at all.
+// A check that fails to pull is logged and retried on the next one
+func runUpdateLoop(
+	ctx context.Context,
+	name string,
+	checkDelay time.Duration,
+	load func() error,
+	check func(ctx context.Context) (shouldDisable bool, err error),
+) {
+
+	err := load()
+	if err != nil {
+		log.Printf("%v", err)
+	}
+
+	for {
+
+		shouldDisable, err := check(ctx)
+		if err != nil {
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			log.Printf("%v", fmt.Errorf("failed to pull %v: %w", name, err))
+		}
+
+		if shouldDisable {
+			log.Printf("Disabling %v due to its error policy", name)
+			return
+		}
+
+		if !sleepContext(ctx, checkDelay) {
+			return
+		}
+	}
+}
+
+// onPostError Logs the failed post, returning whether the error policy skips what failed and whether it disables the module.
+// Retrying is neither, what failed isn't remembered so it's posted again on the next check
+func onPostError(name string, errorPolicy data.ErrorPolicy, err error) (skip bool, disable bool) {
+
+	log.Printf("%v", fmt.Errorf("failed to post updates for %v (%s): %w", name, errorPolicy, err))
+
+	return errorPolicy == data.SkipOnError, errorPolicy == data.DisableOnError
+}
+
+// onRejected Reports a delivery of the source that Discord rejected for good since the last check, ex: the channel was deleted,
+// returning whether the error policy disables the module. It's already in the dead letters, so skipping and retrying both leave it there for /delivery retry
+func onRejected(queue *delivery.Queue, source string, name string, errorPolicy data.ErrorPolicy) bool {
+
+	if queue == nil {
+		return false
+	}
+
+	err := queue.TakeRejection(source)
+	if err == nil {
+		return false
+	}
+
+	_, disable := onPostError(name, errorPolicy, err)
+
+	return disable
+}
+
+// onSaveError Logs the failed save, returning whether the error policy disables the module
+func onSaveError(errorPolicy data.ErrorPolicy, err error) bool {
+
+	log.Printf("%v", err)
+
+	return errorPolicy == data.DisableOnError
+}
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"go.etcd.io/bbolt"
+	"os"
+	"path/filepath"
+	"privateInfoBot/utils"
+	"time"
+)
+
+// stateBucket Every key is in the one bucket, prefixes keep the modules apart like the JSON store's directories
+var stateBucket = []byte("state")
+
+// openTimeout How long OpenBoltStore waits for another process holding the database
+const openTimeout = time.Second * 5
+
+// BoltStore Saves the keys in a bbolt database, a single file whose transactions survive crashes
+type BoltStore struct {
+	db *bbolt.DB
+}
+
+// OpenBoltStore Opens the database, creating it if it doesn't exist
+func OpenBoltStore(filePath string) (*BoltStore, error) {
+
+	err := os.MkdirAll(filepath.Dir(filePath), utils.DefaultDirectoryMode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make directories for the database: %w", err)
+	}
+
+	db, err := bbolt.Open(filePath, 0600, &bbolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the database: %w", err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(stateBucket)
+		return err
+	})
+
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to create the state bucket: %w", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+func (store *BoltStore) Get(key string) ([]byte, error) {
+
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	var value []byte
+
+	err := store.db.View(func(tx *bbolt.Tx) error {
+
+		saved := tx.Bucket(stateBucket).Get([]byte(key))
+		if saved == nil {
+			return ErrNotFound
+		}
+
+		// The saved value is only valid during the transaction
+		value = append([]byte{}, saved...)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (store *BoltStore) Put(key string, value []byte) error {
+
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	err := store.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(stateBucket).Put([]byte(key), value)
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func (store *BoltStore) Delete(key string) error {
+
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	err := store.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(stateBucket).Delete([]byte(key))
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func (store *BoltStore) Keys(prefix string) ([]string, error) {
+
+	var keys []string
+
+	err := store.db.View(func(tx *bbolt.Tx) error {
+
+		cursor := tx.Bucket(stateBucket).Cursor()
+
+		// Keys are sorted, so the ones with the prefix are next to each other
+		for key, _ := cursor.Seek([]byte(prefix)); key != nil && bytes.HasPrefix(key, []byte(prefix)); key, _ = cursor.Next() {
+			keys = append(keys, string(key))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (store *BoltStore) Close() error {
+	return store.db.Close()
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"privateInfoBot/utils"
+	"sort"
+	"strings"
+)
+
+const jsonExtension = ".json"
+
+// JSONStore Saves each key as a JSON file under the directory, ex: "RSS/feed" is "<directory>/RSS/feed.json".
+// It's the layout the bot always used, so the files saved before the store existed are read as is
+type JSONStore struct {
+	directory string
+	// Options How the files are written, they keep a backup by default, which Get falls back to
+	Options utils.FileOptions
+}
+
+func NewJSONStore(directory string) *JSONStore {
+	return &JSONStore{
+		directory: directory,
+		Options:   utils.FileOptions{Backup: true},
+	}
+}
+
+func (store *JSONStore) Get(key string) ([]byte, error) {
+
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	value, err := utils.ReadJsonBytes(store.filePath(key))
+	if err != nil {
+
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+
+	return value, nil
+}
+
+// Put Writes the value atomically, see utils.WriteFileAtomic
+func (store *JSONStore) Put(key string, value []byte) error {
+
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	err := utils.WriteJsonBytes(store.filePath(key), value, store.Options)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// Delete Removes the key and its backup, so it can't be read back from the backup
+func (store *JSONStore) Delete(key string) error {
+
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	filePath := store.filePath(key)
+
+	for _, deletePath := range []string{filePath, filePath + utils.BackupExtension} {
+		err := os.Remove(deletePath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to delete %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+func (store *JSONStore) Keys(prefix string) ([]string, error) {
+
+	var keys []string
+
+	err := filepath.WalkDir(store.directory, func(filePath string, entry fs.DirEntry, err error) error {
+
+		if err != nil {
+
+			if errors.Is(err, os.ErrNotExist) && filePath == store.directory {
+				return filepath.SkipDir
+			}
+
+			return err
+		}
+
+		// Temp files and backups have their own extension after .json
+		if entry.IsDir() || !strings.HasSuffix(filePath, jsonExtension) {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(store.directory, filePath)
+		if err != nil {
+			return err
+		}
+
+		key := strings.TrimSuffix(filepath.ToSlash(relativePath), jsonExtension)
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// Close Nothing is kept open between calls
+func (store *JSONStore) Close() error {
+	return nil
+}
+
+func (store *JSONStore) filePath(key string) string {
+	return filepath.Join(store.directory, filepath.FromSlash(key)+jsonExtension)
+}
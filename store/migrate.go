@@ -0,0 +1,44 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Migrate Copies the keys with the prefix the destination doesn't have yet from the source, ex: importing the JSON files into a new database.
+// Keys the destination already has are newer, so they're kept. The source is left as it was, returning how many keys were copied
+func Migrate(source Store, destination Store, prefix string) (int, error) {
+
+	keys, err := source.Keys(prefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to migrate: %w", err)
+	}
+
+	copied := 0
+
+	for _, key := range keys {
+
+		_, err := destination.Get(key)
+		if err == nil {
+			continue
+		}
+
+		if !errors.Is(err, ErrNotFound) {
+			return copied, fmt.Errorf("failed to migrate: %w", err)
+		}
+
+		value, err := source.Get(key)
+		if err != nil {
+			return copied, fmt.Errorf("failed to migrate: %w", err)
+		}
+
+		err = destination.Put(key, value)
+		if err != nil {
+			return copied, fmt.Errorf("failed to migrate: %w", err)
+		}
+
+		copied++
+	}
+
+	return copied, nil
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"github.com/json-iterator/go"
+	"strings"
+)
+
+// ErrNotFound Returned by Get when nothing is saved under the key
+var ErrNotFound = errors.New("not found")
+
+// Store Saves the modules' state between runs.
+// Keys are slash separated paths, ex: "RSS/kernel.org_feeds_kdist.xml", so modules keep their state apart by prefix
+type Store interface {
+	// Get Returns the value saved under the key, ErrNotFound if there isn't one
+	Get(key string) ([]byte, error)
+	// Put Saves the value under the key, a crash during Put leaves either the old or the new value, never part of one
+	Put(key string, value []byte) error
+	// Delete Removes the key, deleting a missing key isn't an error
+	Delete(key string) error
+	// Keys Returns the keys starting with the prefix, sorted
+	Keys(prefix string) ([]string, error)
+	Close() error
+}
+
+// GetJson Unmarshals the value saved under the key into result, returning false if there isn't one
+func GetJson(store Store, key string, result interface{}) (bool, error) {
+
+	value, err := store.Get(key)
+	if err != nil {
+
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	err = jsoniter.Unmarshal(value, result)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse %s: %w", key, err)
+	}
+
+	return true, nil
+}
+
+// PutJson Saves the value under the key as indented JSON, so the JSON files stay readable
+func PutJson(store Store, key string, value interface{}) error {
+
+	json, err := jsoniter.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", key, err)
+	}
+
+	return store.Put(key, json)
+}
+
+// validateKey Keys become file paths in the JSON store, so they can't be empty or leave its directory
+func validateKey(key string) error {
+
+	if key == "" || strings.Contains(key, `\`) {
+		return fmt.Errorf("invalid key: %q", key)
+	}
+
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("invalid key: %q", key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,321 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/bbolt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"privateInfoBot/utils"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+const (
+	// crashEnv Tells the test binary it's the child process of a crash test, and where its store is
+	crashEnv = "STORE_CRASH_DIRECTORY"
+	// crashExitCode Tells a crash apart from a failed test in the child process
+	crashExitCode = 3
+)
+
+// stores Both backends, opened in a temp directory
+func stores(test *testing.T) map[string]Store {
+
+	boltStore, err := OpenBoltStore(filepath.Join(test.TempDir(), "state.db"))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	test.Cleanup(func() { _ = boltStore.Close() })
+
+	return map[string]Store{
+		"json": NewJSONStore(test.TempDir()),
+		"bolt": boltStore,
+	}
+}
+
+func TestStore(test *testing.T) {
+
+	for name, store := range stores(test) {
+		test.Run(name, func(test *testing.T) {
+
+			_, err := store.Get("RSS/feed")
+			assert.ErrorIs(test, err, ErrNotFound)
+
+			assert.NoError(test, store.Put("RSS/feed", []byte(`{"version": 2}`)))
+			assert.NoError(test, store.Put("RSS/feed.seen", []byte(`{"seen": {}}`)))
+			assert.NoError(test, store.Put("Scrape/roadmap", []byte(`[]`)))
+
+			value, err := store.Get("RSS/feed")
+			assert.NoError(test, err)
+			assert.Equal(test, `{"version": 2}`, string(value))
+
+			assert.NoError(test, store.Put("RSS/feed", []byte(`{"version": 3}`)))
+
+			value, err = store.Get("RSS/feed")
+			assert.NoError(test, err)
+			assert.Equal(test, `{"version": 3}`, string(value))
+
+			keys, err := store.Keys("RSS/")
+			assert.NoError(test, err)
+			assert.Equal(test, []string{"RSS/feed", "RSS/feed.seen"}, keys)
+
+			keys, err = store.Keys("")
+			assert.NoError(test, err)
+			assert.Equal(test, []string{"RSS/feed", "RSS/feed.seen", "Scrape/roadmap"}, keys)
+
+			assert.NoError(test, store.Delete("RSS/feed"))
+			assert.NoError(test, store.Delete("RSS/feed"), "deleting a missing key isn't an error")
+
+			_, err = store.Get("RSS/feed")
+			assert.ErrorIs(test, err, ErrNotFound)
+
+			for _, key := range []string{"", "/RSS", "RSS/", "RSS//feed", "../feed", "RSS/../../feed", `RSS\feed`} {
+				assert.Error(test, store.Put(key, []byte(`{}`)), key)
+			}
+		})
+	}
+}
+
+func TestJson(test *testing.T) {
+
+	type state struct {
+		Items []string `json:"items"`
+	}
+
+	for name, store := range stores(test) {
+		test.Run(name, func(test *testing.T) {
+
+			var read state
+
+			found, err := GetJson(store, "Scrape/roadmap", &read)
+			assert.NoError(test, err)
+			assert.False(test, found)
+
+			assert.NoError(test, PutJson(store, "Scrape/roadmap", state{Items: []string{"a", "b"}}))
+
+			found, err = GetJson(store, "Scrape/roadmap", &read)
+			assert.NoError(test, err)
+			assert.True(test, found)
+			assert.Equal(test, state{Items: []string{"a", "b"}}, read)
+
+			assert.NoError(test, store.Put("Scrape/broken", []byte(`{"items": [`)))
+
+			_, err = GetJson(store, "Scrape/broken", &read)
+			assert.Error(test, err)
+		})
+	}
+}
+
+func TestJSONStore_layout(test *testing.T) {
+
+	directory := test.TempDir()
+
+	// Saved before the store existed
+	assert.NoError(test, os.MkdirAll(filepath.Join(directory, "RSS"), 0755))
+	assert.NoError(test, os.WriteFile(filepath.Join(directory, "RSS", "kernel.org_feeds_kdist.xml.json"), []byte(`[]`), 0644))
+
+	store := NewJSONStore(directory)
+
+	value, err := store.Get("RSS/kernel.org_feeds_kdist.xml")
+	assert.NoError(test, err)
+	assert.Equal(test, `[]`, string(value))
+
+	assert.NoError(test, store.Put("PageChange/drivers", []byte(`{}`)))
+
+	info, err := os.Stat(filepath.Join(directory, "PageChange", "drivers.json"))
+	assert.NoError(test, err)
+	assert.Equal(test, utils.DefaultFileMode, info.Mode().Perm())
+
+	store.Options.FileMode = 0600
+	assert.NoError(test, store.Put("PageChange/private", []byte(`{}`)))
+
+	info, err = os.Stat(filepath.Join(directory, "PageChange", "private.json"))
+	assert.NoError(test, err)
+	assert.Equal(test, os.FileMode(0600), info.Mode().Perm())
+
+	keys, err := NewJSONStore(filepath.Join(directory, "missing")).Keys("")
+	assert.NoError(test, err)
+	assert.Empty(test, keys)
+}
+
+func TestJSONStore_backup(test *testing.T) {
+
+	directory := test.TempDir()
+	store := NewJSONStore(directory)
+
+	assert.NoError(test, store.Put("Scrape/roadmap", []byte(`["first"]`)))
+	assert.NoError(test, store.Put("Scrape/roadmap", []byte(`["second"]`)))
+
+	// Corrupted outside of Put, ex: by a disk error
+	assert.NoError(test, os.WriteFile(filepath.Join(directory, "Scrape", "roadmap.json"), []byte(`["sec`), 0644))
+
+	value, err := store.Get("Scrape/roadmap")
+	assert.NoError(test, err)
+	assert.Equal(test, `["first"]`, string(value), "the backup should be read instead")
+
+	keys, err := store.Keys("")
+	assert.NoError(test, err)
+	assert.Equal(test, []string{"Scrape/roadmap"}, keys, "backups aren't keys")
+
+	// The corrupted file doesn't replace the good backup
+	assert.NoError(test, store.Put("Scrape/roadmap", []byte(`["third"]`)))
+
+	backup, err := os.ReadFile(filepath.Join(directory, "Scrape", "roadmap.json"+utils.BackupExtension))
+	assert.NoError(test, err)
+	assert.Equal(test, `["first"]`, string(backup))
+
+	assert.NoError(test, store.Delete("Scrape/roadmap"))
+
+	_, err = store.Get("Scrape/roadmap")
+	assert.ErrorIs(test, err, ErrNotFound, "deleting should remove the backup too")
+}
+
+func TestMigrate(test *testing.T) {
+
+	jsonStore := NewJSONStore(test.TempDir())
+	assert.NoError(test, jsonStore.Put("RSS/a", []byte(`"a from json"`)))
+	assert.NoError(test, jsonStore.Put("RSS/b", []byte(`"b from json"`)))
+	assert.NoError(test, jsonStore.Put("Delivery/queue", []byte(`[]`)))
+
+	boltStore := stores(test)["bolt"]
+	assert.NoError(test, boltStore.Put("RSS/b", []byte(`"b from bolt"`)))
+
+	copied, err := Migrate(jsonStore, boltStore, "RSS/")
+	assert.NoError(test, err)
+	assert.Equal(test, 1, copied)
+
+	value, err := boltStore.Get("RSS/a")
+	assert.NoError(test, err)
+	assert.Equal(test, `"a from json"`, string(value))
+
+	value, err = boltStore.Get("RSS/b")
+	assert.NoError(test, err)
+	assert.Equal(test, `"b from bolt"`, string(value), "keys the destination has are newer")
+
+	_, err = boltStore.Get("Delivery/queue")
+	assert.ErrorIs(test, err, ErrNotFound, "only keys with the prefix are migrated")
+
+	// Running it again doesn't copy anything, so it's safe on every start
+	copied, err = Migrate(jsonStore, boltStore, "RSS/")
+	assert.NoError(test, err)
+	assert.Equal(test, 0, copied)
+}
+
+// runCrash Runs the test in a child process that exits in the middle of a write, then checks how it exited
+func runCrash(test *testing.T, testName string, directory string) {
+
+	command := exec.Command(os.Args[0], "-test.run=^"+testName+"$")
+	command.Env = append(os.Environ(), crashEnv+"="+directory)
+
+	output, err := command.CombinedOutput()
+
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != crashExitCode {
+		test.Fatalf("the child process didn't crash where expected: %v\n%s", err, output)
+	}
+}
+
+// TestJSONStore_crashDuringPut Kills a child process that keeps rewriting a key, the file has to hold one of the values whole whenever it dies
+func TestJSONStore_crashDuringPut(test *testing.T) {
+
+	values := []string{`"old"`, `["` + strings.Repeat("a", 1<<20) + `"]`, `["` + strings.Repeat("b", 1<<20) + `"]`}
+
+	if directory := os.Getenv(crashEnv); directory != "" {
+
+		store := NewJSONStore(directory)
+
+		for i := 0; ; i++ {
+
+			_ = store.Put("RSS/feed", []byte(values[1+i%2]))
+
+			if i == 0 {
+				// Tells the parent the writes started
+				_ = os.WriteFile(filepath.Join(directory, "started"), nil, 0644)
+			}
+		}
+	}
+
+	directory := test.TempDir()
+	store := NewJSONStore(directory)
+
+	assert.NoError(test, store.Put("RSS/feed", []byte(values[0])))
+
+	command := exec.Command(os.Args[0], "-test.run=^TestJSONStore_crashDuringPut$")
+	command.Env = append(os.Environ(), crashEnv+"="+directory)
+
+	if err := command.Start(); err != nil {
+		test.Fatal(err)
+	}
+
+	for deadline := time.Now().Add(time.Second * 10); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if _, err := os.Stat(filepath.Join(directory, "started")); err == nil {
+			break
+		}
+	}
+
+	time.Sleep(time.Millisecond * 20)
+
+	assert.NoError(test, command.Process.Signal(syscall.SIGKILL))
+	_ = command.Wait()
+
+	value, err := store.Get("RSS/feed")
+	assert.NoError(test, err)
+	assert.Contains(test, values, string(value), "the value should be whole")
+
+	keys, err := store.Keys("")
+	assert.NoError(test, err)
+	assert.Equal(test, []string{"RSS/feed"}, keys, "temp files aren't keys")
+
+	assert.NoError(test, store.Put("RSS/feed", []byte(`"newer"`)))
+
+	tempPaths, _ := filepath.Glob(filepath.Join(directory, "RSS", "*.tmp"))
+	assert.Empty(test, tempPaths, "the next Put should remove any temp file")
+}
+
+func TestBoltStore_crashDuringPut(test *testing.T) {
+
+	if directory := os.Getenv(crashEnv); directory != "" {
+
+		store, err := OpenBoltStore(filepath.Join(directory, "state.db"))
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		// Exits after the value is written to the transaction, before it commits
+		_ = store.db.Update(func(tx *bbolt.Tx) error {
+			_ = tx.Bucket(stateBucket).Put([]byte("RSS/feed"), []byte(strings.Repeat(`"new"`, 1000)))
+			os.Exit(crashExitCode)
+			return nil
+		})
+
+		test.Fatal("Update returned instead of crashing")
+	}
+
+	directory := test.TempDir()
+
+	store, err := OpenBoltStore(filepath.Join(directory, "state.db"))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	assert.NoError(test, store.Put("RSS/feed", []byte(`"old"`)))
+	assert.NoError(test, store.Close())
+
+	runCrash(test, "TestBoltStore_crashDuringPut", directory)
+
+	store, err = OpenBoltStore(filepath.Join(directory, "state.db"))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	defer store.Close()
+
+	value, err := store.Get("RSS/feed")
+	assert.NoError(test, err)
+	assert.Equal(test, `"old"`, string(value), "the uncommitted value should be lost, not the old one")
+
+	assert.NoError(test, store.Put("RSS/feed", []byte(`"newer"`)))
+}
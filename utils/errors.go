@@ -0,0 +1,29 @@
+package utils
+
+import "strings"
+
+type multiError []error
+
+func (errs multiError) Error() string {
+
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// JoinErrors Combines the errors into one, returning nil if there are none
+func JoinErrors(errs []error) error {
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	if len(errs) == 1 {
+		return errs[0]
+	}
+
+	return multiError(errs)
+}
@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+)
+
+const (
+	DefaultFileMode      os.FileMode = 0644
+	DefaultDirectoryMode os.FileMode = 0755
+	// BackupExtension The previous generation of a file written with Backup, ex: "queue.json.bak"
+	BackupExtension = ".bak"
+	// tempExtension Marks files being written, a crash can leave one behind, which the next write removes
+	tempExtension = ".tmp"
+)
+
+// afterTempWrite Called once the temp file is written, before it replaces the file, so tests can crash there
+var afterTempWrite func(tempPath string)
+
+// FileOptions How WriteFileAtomic writes, zero modes are the defaults
+type FileOptions struct {
+	FileMode      os.FileMode
+	DirectoryMode os.FileMode
+	// Backup Keeps the file's previous contents as its .bak before replacing it
+	Backup bool
+	// keepBackup Whether the current contents are worth keeping, a corrupted file shouldn't replace a good backup
+	keepBackup func(current []byte) bool
+}
+
+func (options FileOptions) fileMode() os.FileMode {
+
+	if options.FileMode == 0 {
+		return DefaultFileMode
+	}
+
+	return options.FileMode
+}
+
+func (options FileOptions) directoryMode() os.FileMode {
+
+	if options.DirectoryMode == 0 {
+		return DefaultDirectoryMode
+	}
+
+	return options.DirectoryMode
+}
+
+// WriteFileAtomic Writes the data to a temp file next to the file, syncs it, then renames it over the file.
+// A crash leaves either the old or the new contents, never part of them
+func WriteFileAtomic(filePath string, data []byte, options FileOptions) error {
+
+	directory := filepath.Dir(filePath)
+
+	err := os.MkdirAll(directory, options.directoryMode())
+	if err != nil {
+		return errors.Wrap(err, "failed to make directories")
+	}
+
+	if options.Backup {
+		err = backupFile(filePath, options)
+		if err != nil {
+			return errors.Wrap(err, "failed to back up file")
+		}
+	}
+
+	err = replaceFile(filePath, data, options.fileMode())
+	if err != nil {
+		return errors.Wrap(err, "failed to write file")
+	}
+
+	removeTempFiles(filePath)
+
+	return nil
+}
+
+// backupFile Copies the file to its .bak, atomically so a crash can't leave both broken
+func backupFile(filePath string, options FileOptions) error {
+
+	current, err := os.ReadFile(filePath)
+	if err != nil {
+
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	if options.keepBackup != nil && !options.keepBackup(current) {
+		return nil
+	}
+
+	err = replaceFile(filePath+BackupExtension, current, options.fileMode())
+	if err != nil {
+		return err
+	}
+
+	removeTempFiles(filePath + BackupExtension)
+
+	return nil
+}
+
+func replaceFile(filePath string, data []byte, fileMode os.FileMode) error {
+
+	directory := filepath.Dir(filePath)
+
+	file, err := os.CreateTemp(directory, filepath.Base(filePath)+".*"+tempExtension)
+	if err != nil {
+		return err
+	}
+
+	tempPath := file.Name()
+
+	err = writeAndSync(file, data, fileMode)
+	if err != nil {
+		_ = os.Remove(tempPath)
+		return err
+	}
+
+	if afterTempWrite != nil {
+		afterTempWrite(tempPath)
+	}
+
+	err = os.Rename(tempPath, filePath)
+	if err != nil {
+		_ = os.Remove(tempPath)
+		return err
+	}
+
+	// The rename is only durable once the directory is synced
+	return syncDirectory(directory)
+}
+
+func writeAndSync(file *os.File, data []byte, fileMode os.FileMode) error {
+
+	// CreateTemp makes the file 0600, the mode is set before it replaces anything
+	err := file.Chmod(fileMode)
+
+	if err == nil {
+		_, err = file.Write(data)
+	}
+
+	if err == nil {
+		err = file.Sync()
+	}
+
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+func syncDirectory(directory string) error {
+
+	file, err := os.Open(directory)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	return file.Sync()
+}
+
+// removeTempFiles Removes the temp files left by crashes while writing the file
+func removeTempFiles(filePath string) {
+
+	tempPaths, err := filepath.Glob(filePath + ".*" + tempExtension)
+	if err != nil {
+		return
+	}
+
+	for _, tempPath := range tempPaths {
+		_ = os.Remove(tempPath)
+	}
+}
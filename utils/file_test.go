@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	// crashEnv Tells the test binary it's the child process of the crash test, and which file it writes
+	crashEnv = "UTILS_CRASH_FILE"
+	// crashExitCode Tells the crash apart from a failed test in the child process
+	crashExitCode = 3
+)
+
+func TestWriteFileAtomic_modes(test *testing.T) {
+
+	directory := filepath.Join(test.TempDir(), "state")
+
+	filePath := filepath.Join(directory, "default.json")
+	assert.NoError(test, WriteJsonAfterMakeDirs(filePath, []string{"a"}))
+
+	info, err := os.Stat(filePath)
+	assert.NoError(test, err)
+	assert.Equal(test, DefaultFileMode, info.Mode().Perm())
+
+	// The umask can only take bits away from directories
+	info, err = os.Stat(directory)
+	assert.NoError(test, err)
+	assert.Zero(test, info.Mode().Perm()&^DefaultDirectoryMode, "directories shouldn't be writable by others")
+
+	privateDirectory := filepath.Join(test.TempDir(), "private")
+	privatePath := filepath.Join(privateDirectory, "private.json")
+	assert.NoError(test, WriteJson(privatePath, []string{"a"}, FileOptions{FileMode: 0600, DirectoryMode: 0700}))
+
+	info, err = os.Stat(privatePath)
+	assert.NoError(test, err)
+	assert.Equal(test, os.FileMode(0600), info.Mode().Perm())
+
+	info, err = os.Stat(privateDirectory)
+	assert.NoError(test, err)
+	assert.Equal(test, os.FileMode(0700), info.Mode().Perm())
+
+	// Replacing a file gives it the new mode too
+	assert.NoError(test, WriteJson(filePath, []string{"b"}, FileOptions{FileMode: 0600}))
+
+	info, err = os.Stat(filePath)
+	assert.NoError(test, err)
+	assert.Equal(test, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestReadJson_backup(test *testing.T) {
+
+	filePath := filepath.Join(test.TempDir(), "state.json")
+
+	var result []string
+
+	found, err := ReadJson(filePath, &result)
+	assert.NoError(test, err)
+	assert.False(test, found)
+
+	assert.NoError(test, WriteJson(filePath, []string{"first"}, FileOptions{Backup: true}))
+
+	_, err = os.Stat(filePath + BackupExtension)
+	assert.ErrorIs(test, err, os.ErrNotExist, "there's nothing to back up on the first write")
+
+	assert.NoError(test, WriteJson(filePath, []string{"second"}, FileOptions{Backup: true}))
+
+	found, err = ReadJson(filePath, &result)
+	assert.NoError(test, err)
+	assert.True(test, found)
+	assert.Equal(test, []string{"second"}, result)
+
+	assert.NoError(test, os.WriteFile(filePath, []byte(`["sec`), 0644))
+
+	found, err = ReadJson(filePath, &result)
+	assert.NoError(test, err)
+	assert.True(test, found)
+	assert.Equal(test, []string{"first"}, result, "the backup should be read when the file doesn't parse")
+
+	// The corrupted file isn't worth backing up
+	assert.NoError(test, WriteJson(filePath, []string{"third"}, FileOptions{Backup: true}))
+
+	backup, err := ReadJsonBytes(filePath + BackupExtension)
+	assert.NoError(test, err)
+	assert.JSONEq(test, `["first"]`, string(backup))
+
+	// Without a valid backup a file that doesn't parse is an error
+	assert.NoError(test, os.WriteFile(filePath, []byte(`["thi`), 0644))
+	assert.NoError(test, os.WriteFile(filePath+BackupExtension, []byte(`["fir`), 0644))
+
+	_, err = ReadJson(filePath, &result)
+	assert.Error(test, err)
+
+	// Deleting the file resets it, the backup isn't read
+	assert.NoError(test, os.Remove(filePath))
+	assert.NoError(test, WriteJsonAfterMakeDirs(filePath+BackupExtension, []string{"first"}))
+
+	found, err = ReadJson(filePath, &result)
+	assert.NoError(test, err)
+	assert.False(test, found)
+}
+
+// TestWriteFileAtomic_crash Runs itself in a child process that exits once the file's temp file is written, before the rename
+func TestWriteFileAtomic_crash(test *testing.T) {
+
+	if filePath := os.Getenv(crashEnv); filePath != "" {
+
+		// The backup is written first, the crash is on the file itself
+		afterTempWrite = func(tempPath string) {
+			if !strings.Contains(filepath.Base(tempPath), BackupExtension) {
+				os.Exit(crashExitCode)
+			}
+		}
+
+		_ = WriteJson(filePath, []string{"new"}, FileOptions{Backup: true})
+
+		test.Fatal("WriteJson returned instead of crashing")
+	}
+
+	filePath := filepath.Join(test.TempDir(), "state.json")
+	assert.NoError(test, WriteJsonAfterMakeDirs(filePath, []string{"old"}))
+
+	command := exec.Command(os.Args[0], "-test.run=^TestWriteFileAtomic_crash$")
+	command.Env = append(os.Environ(), crashEnv+"="+filePath)
+
+	output, err := command.CombinedOutput()
+
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != crashExitCode {
+		test.Fatalf("the child process didn't crash where expected: %v\n%s", err, output)
+	}
+
+	var result []string
+
+	found, err := ReadJson(filePath, &result)
+	assert.NoError(test, err)
+	assert.True(test, found)
+	assert.Equal(test, []string{"old"}, result, "the old contents should be kept whole")
+
+	backup, err := ReadJsonBytes(filePath + BackupExtension)
+	assert.NoError(test, err)
+	assert.JSONEq(test, `["old"]`, string(backup), "the backup was written before the crash")
+
+	tempPaths, _ := filepath.Glob(filepath.Join(filepath.Dir(filePath), "*"+tempExtension))
+	assert.Len(test, tempPaths, 1, "the crash should leave the temp file behind")
+
+	assert.NoError(test, WriteJson(filePath, []string{"newer"}, FileOptions{Backup: true}))
+
+	tempPaths, _ = filepath.Glob(filepath.Join(filepath.Dir(filePath), "*"+tempExtension))
+	assert.Empty(test, tempPaths, "the next write should remove the temp file")
+}
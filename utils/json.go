@@ -3,26 +3,80 @@ package utils
 import (
 	"github.com/json-iterator/go"
 	"github.com/pkg/errors"
+	"log"
 	"os"
-	"path"
 )
 
+// jsonFileOptions Only files that parse become backups, so a corrupted file can't replace a good one
+func jsonFileOptions(options FileOptions) FileOptions {
+	options.keepBackup = jsoniter.Valid
+	return options
+}
+
+// WriteJsonAfterMakeDirs Writes the data as indented JSON with the default modes and without a backup
 func WriteJsonAfterMakeDirs(filePath string, data interface{}) error {
+	return WriteJson(filePath, data, FileOptions{})
+}
 
-	err := os.MkdirAll(path.Dir(filePath), os.ModePerm)
-	if err != nil {
-		return errors.Wrap(err, "failed to make directories")
-	}
+// WriteJson Writes the data as indented JSON, atomically
+func WriteJson(filePath string, data interface{}, options FileOptions) error {
 
 	json, err := jsoniter.MarshalIndent(data, "", "  ")
 	if err != nil {
 		return errors.Wrap(err, "failed to marshal json")
 	}
 
-	err = os.WriteFile(filePath, json, os.ModePerm)
+	return WriteJsonBytes(filePath, json, options)
+}
+
+// WriteJsonBytes Writes JSON that's already marshaled, atomically
+func WriteJsonBytes(filePath string, json []byte, options FileOptions) error {
+	return WriteFileAtomic(filePath, json, jsonFileOptions(options))
+}
+
+// ReadJsonBytes Reads the JSON file, falling back to its .bak when it doesn't parse.
+// A missing file is os.ErrNotExist even if there's a backup, since deleting the file is how its state is reset
+func ReadJsonBytes(filePath string) ([]byte, error) {
+
+	json, err := os.ReadFile(filePath)
+	if err != nil && errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if err == nil && jsoniter.Valid(json) {
+		return json, nil
+	}
+
+	backup, backupErr := os.ReadFile(filePath + BackupExtension)
+	if backupErr == nil && jsoniter.Valid(backup) {
+		log.Printf("%s doesn't parse, reading its backup instead", filePath)
+		return backup, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read file")
+	}
+
+	return nil, errors.Errorf("%s isn't valid json and has no valid backup", filePath)
+}
+
+// ReadJson Unmarshals the JSON file into result, falling back to its .bak when it doesn't parse, returning false if it doesn't exist
+func ReadJson(filePath string, result interface{}) (bool, error) {
+
+	json, err := ReadJsonBytes(filePath)
+	if err != nil {
+
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	err = jsoniter.Unmarshal(json, result)
 	if err != nil {
-		return errors.Wrap(err, "failed to write file")
+		return false, errors.Wrap(err, "failed to parse json")
 	}
 
-	return nil
+	return true, nil
 }
@@ -0,0 +1,323 @@
+package utils
+
+import (
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	markdownEscaper   = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`)
+	whitespacePattern = regexp.MustCompile(`[ \t\r\n]+`)
+	blankLinesPattern = regexp.MustCompile(`\n[ \t]*\n[ \t\n]*`)
+)
+
+// ConvertedHTML HTML converted to Discord markdown
+type ConvertedHTML struct {
+	Markdown string
+	// ImageURL The source of the first image, which is left out of the markdown since Discord can't show images inline
+	ImageURL string
+}
+
+// HTMLToMarkdown Converts HTML, ex: an item's description, to Discord markdown, leaving out images
+func HTMLToMarkdown(htmlText string) string {
+	return ConvertHTML(htmlText).Markdown
+}
+
+// ConvertHTML Converts HTML to Discord markdown: bold, italics, links, code, lists and quotes, scripts and styles are dropped
+func ConvertHTML(htmlText string) ConvertedHTML {
+
+	document, err := goquery.NewDocumentFromReader(strings.NewReader(htmlText))
+	if err != nil {
+		return ConvertedHTML{Markdown: strings.TrimSpace(html.UnescapeString(htmlText))}
+	}
+
+	converter := &htmlConverter{}
+
+	var builder strings.Builder
+	for _, node := range document.Nodes {
+		converter.writeChildren(&builder, node)
+	}
+
+	return ConvertedHTML{
+		Markdown: cleanMarkdown(builder.String()),
+		ImageURL: converter.imageURL,
+	}
+}
+
+type htmlConverter struct {
+	imageURL  string
+	listDepth int
+}
+
+func (converter *htmlConverter) writeChildren(builder *strings.Builder, node *html.Node) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		converter.write(builder, child)
+	}
+}
+
+// children Converts the node's children on their own, for elements that wrap or prefix them
+func (converter *htmlConverter) children(node *html.Node) string {
+
+	var builder strings.Builder
+	converter.writeChildren(&builder, node)
+
+	return builder.String()
+}
+
+func (converter *htmlConverter) write(builder *strings.Builder, node *html.Node) {
+
+	switch node.Type {
+
+	case html.TextNode:
+		builder.WriteString(escapeMarkdown(whitespacePattern.ReplaceAllString(node.Data, " ")))
+		return
+
+	case html.ElementNode:
+		// Handled below
+
+	case html.DocumentNode:
+		converter.writeChildren(builder, node)
+		return
+
+	default:
+		return
+	}
+
+	switch node.DataAtom {
+
+	case atom.Script, atom.Style, atom.Noscript, atom.Iframe, atom.Head, atom.Template, atom.Svg:
+		return
+
+	case atom.Img:
+		if src := attribute(node, "src"); converter.imageURL == "" && isWebURL(src) {
+			converter.imageURL = src
+		}
+
+	case atom.Br:
+		builder.WriteString("\n")
+
+	case atom.Hr:
+		builder.WriteString("\n\n───\n\n")
+
+	case atom.B, atom.Strong:
+		builder.WriteString(wrapInline(converter.children(node), "**"))
+
+	case atom.I, atom.Em, atom.Cite:
+		builder.WriteString(wrapInline(converter.children(node), "*"))
+
+	case atom.U, atom.Ins:
+		builder.WriteString(wrapInline(converter.children(node), "__"))
+
+	case atom.S, atom.Strike, atom.Del:
+		builder.WriteString(wrapInline(converter.children(node), "~~"))
+
+	case atom.Code, atom.Kbd, atom.Samp:
+		// Code isn't escaped, backticks inside it end it early so they're dropped
+		code := strings.ReplaceAll(textContent(node), "`", "")
+		if strings.TrimSpace(code) != "" {
+			builder.WriteString("`" + code + "`")
+		}
+
+	case atom.Pre:
+		code := strings.ReplaceAll(strings.Trim(textContent(node), "\n"), "```", "'''")
+		builder.WriteString("\n\n```\n" + code + "\n```\n\n")
+
+	case atom.A:
+		text := strings.TrimSpace(converter.children(node))
+		href := attribute(node, "href")
+
+		switch {
+		case !isWebURL(href):
+			builder.WriteString(text)
+		case text == "" || text == href:
+			builder.WriteString(href)
+		default:
+			builder.WriteString("[" + text + "](" + href + ")")
+		}
+
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		builder.WriteString("\n\n" + wrapInline(converter.children(node), "**") + "\n\n")
+
+	case atom.Blockquote:
+		quote := cleanMarkdown(converter.children(node))
+		if quote != "" {
+			builder.WriteString("\n\n> " + strings.ReplaceAll(quote, "\n", "\n> ") + "\n\n")
+		}
+
+	case atom.Ul, atom.Ol:
+		converter.writeList(builder, node)
+
+	case atom.Li:
+		// Outside of a list
+		builder.WriteString("\n- " + strings.TrimSpace(converter.children(node)) + "\n")
+
+	case atom.Tr:
+		var cells []string
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.DataAtom == atom.Td || child.DataAtom == atom.Th {
+				if cell := strings.TrimSpace(converter.children(child)); cell != "" {
+					cells = append(cells, cell)
+				}
+			}
+		}
+		if !strings.HasSuffix(builder.String(), "\n") {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(strings.Join(cells, " | ") + "\n")
+
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer, atom.Table, atom.Figure, atom.Dl:
+		builder.WriteString("\n\n" + converter.children(node) + "\n\n")
+
+	default:
+		converter.writeChildren(builder, node)
+	}
+}
+
+func (converter *htmlConverter) writeList(builder *strings.Builder, node *html.Node) {
+
+	indent := strings.Repeat("  ", converter.listDepth)
+	isOrdered := node.DataAtom == atom.Ol
+
+	converter.listDepth++
+	defer func() { converter.listDepth-- }()
+
+	builder.WriteString("\n")
+
+	number := 1
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+
+		if child.DataAtom != atom.Li {
+			continue
+		}
+
+		marker := "- "
+		if isOrdered {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+
+		item := strings.TrimSpace(blankLinesPattern.ReplaceAllString(converter.children(child), "\n"))
+		builder.WriteString(indent + marker + item + "\n")
+	}
+
+	if converter.listDepth == 1 {
+		builder.WriteString("\n")
+	}
+}
+
+// wrapInline Wraps the text in the markers, keeping surrounding spaces outside since Discord ignores markers next to spaces
+func wrapInline(text string, marker string) string {
+
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+
+	leading := text[:strings.Index(text, trimmed)]
+	trailing := text[len(leading)+len(trimmed):]
+
+	return leading + marker + trimmed + marker + trailing
+}
+
+// escapeMarkdown Escapes the characters Discord would format, except in URLs so they stay clickable
+func escapeMarkdown(text string) string {
+
+	words := strings.Split(text, " ")
+	for i, word := range words {
+		if !isWebURL(word) {
+			words[i] = markdownEscaper.Replace(word)
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// cleanMarkdown Removes the blank lines and spaces left around blocks, leaving code blocks as they are
+func cleanMarkdown(markdown string) string {
+
+	var lines []string
+	isInCode := false
+	isBlank := true
+
+	for _, line := range strings.Split(markdown, "\n") {
+
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			isInCode = !isInCode
+			lines = append(lines, strings.TrimSpace(line))
+			isBlank = false
+			continue
+		}
+
+		if isInCode {
+			lines = append(lines, line)
+			continue
+		}
+
+		line = strings.TrimRight(line, " \t")
+
+		// Spaces at the start of list items are indentation
+		if !strings.HasPrefix(strings.TrimLeft(line, " "), "- ") && !isNumberedLine(line) {
+			line = strings.TrimLeft(line, " \t")
+		}
+
+		if line == "" {
+			if !isBlank {
+				lines = append(lines, line)
+			}
+			isBlank = true
+			continue
+		}
+
+		lines = append(lines, line)
+		isBlank = false
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func isNumberedLine(line string) bool {
+
+	trimmed := strings.TrimLeft(line, " ")
+	dot := strings.Index(trimmed, ". ")
+	if dot <= 0 {
+		return false
+	}
+
+	_, err := strconv.Atoi(trimmed[:dot])
+
+	return err == nil
+}
+
+// textContent The node's text without any conversion, for code
+func textContent(node *html.Node) string {
+
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+
+	var builder strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		builder.WriteString(textContent(child))
+	}
+
+	return builder.String()
+}
+
+func attribute(node *html.Node, name string) string {
+
+	for _, attr := range node.Attr {
+		if attr.Key == name {
+			return attr.Val
+		}
+	}
+
+	return ""
+}
+
+func isWebURL(url string) bool {
+	return strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://")
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestConvertHTML(test *testing.T) {
+
+	tests := []struct {
+		testName         string
+		html             string
+		expectedMarkdown string
+		expectedImageURL string
+	}{
+		{
+			testName:         "inline",
+			html:             `<p>Some <b>bold</b>, <em>italic</em> and <code>code</code> with a <a href="https://example.com/a">link</a>.</p>`,
+			expectedMarkdown: "Some **bold**, *italic* and `code` with a [link](https://example.com/a).",
+		},
+		{
+			testName:         "paragraphs",
+			html:             "<p>First\n  paragraph</p>\n\n\n<p>Second<br>line</p>",
+			expectedMarkdown: "First paragraph\n\nSecond\nline",
+		},
+		{
+			testName:         "escapes",
+			html:             `<p>2 * 3 = 6_ish, see https://example.com/a_b</p>`,
+			expectedMarkdown: `2 \* 3 = 6\_ish, see https://example.com/a_b`,
+		},
+		{
+			testName:         "lists",
+			html:             `<ul><li>One</li><li>Two<ol><li>Nested</li></ol></li></ul><p>After</p>`,
+			expectedMarkdown: "- One\n- Two\n  1. Nested\n\nAfter",
+		},
+		{
+			testName:         "codeBlock",
+			html:             "<pre><code>func main() {\n    fmt.Println(\"*hi*\")\n}</code></pre>",
+			expectedMarkdown: "```\nfunc main() {\n    fmt.Println(\"*hi*\")\n}\n```",
+		},
+		{
+			testName:         "blockquote",
+			html:             `<blockquote><p>Quoted</p><p>Twice</p></blockquote>`,
+			expectedMarkdown: "> Quoted\n>\n> Twice",
+		},
+		{
+			testName:         "scriptsAndImages",
+			html:             `<script>alert(1)</script><style>p {}</style><img src="https://example.com/1.png"><p>Text</p><img src="https://example.com/2.png">`,
+			expectedMarkdown: "Text",
+			expectedImageURL: "https://example.com/1.png",
+		},
+		{
+			testName:         "table",
+			html:             `<table><tr><th>Version:</th><td><strong>6.0</strong> (mainline)</td></tr><tr><th>Released:</th><td>2022-10-02</td></tr></table>`,
+			expectedMarkdown: "Version: | **6.0** (mainline)\nReleased: | 2022-10-02",
+		},
+		{
+			testName:         "bareLink",
+			html:             `<a href="https://example.com/a">https://example.com/a</a> <a href="/relative">relative</a>`,
+			expectedMarkdown: "https://example.com/a relative",
+		},
+	}
+
+	for _, testData := range tests {
+		test.Run(testData.testName, func(test *testing.T) {
+
+			converted := ConvertHTML(testData.html)
+			assert.Equal(test, testData.expectedMarkdown, converted.Markdown)
+			assert.Equal(test, testData.expectedImageURL, converted.ImageURL)
+		})
+	}
+}
@@ -41,3 +41,18 @@ func SubstringBeforeLast(original string, substr string) string {
 
 	return original[:index]
 }
+
+// Truncate Cuts the text to at most maxLength runes, ending it with "…" if it was cut
+func Truncate(text string, maxLength int) string {
+
+	runes := []rune(text)
+	if len(runes) <= maxLength {
+		return text
+	}
+
+	if maxLength <= 0 {
+		return ""
+	}
+
+	return string(runes[:maxLength-1]) + "…"
+}
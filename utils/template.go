@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"fmt"
+	"text/template"
+	"time"
+)
+
+// TemplateFuncs The helpers available to message templates
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		// Ex: {{ .Item.Description | markdown | truncate 200 }}
+		"truncate": func(maxLength int, text string) string {
+			return Truncate(text, maxLength)
+		},
+		"markdown": HTMLToMarkdown,
+		// Ex: {{ date "02 Jan 2006" .Item.PublishedParsed }}
+		"date": formatDate,
+	}
+}
+
+// formatDate Formats a time.Time, a *time.Time or a date string gofeed couldn't parse, nil formats as ""
+func formatDate(layout string, value interface{}) (string, error) {
+
+	switch date := value.(type) {
+	case time.Time:
+		return date.Format(layout), nil
+	case *time.Time:
+		if date == nil {
+			return "", nil
+		}
+		return date.Format(layout), nil
+	case string:
+		return date, nil
+	case nil:
+		return "", nil
+	}
+
+	return "", fmt.Errorf("date can't format %T", value)
+}